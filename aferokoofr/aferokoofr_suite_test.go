@@ -0,0 +1,13 @@
+package aferokoofr_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAferokoofr(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Aferokoofr Suite")
+}
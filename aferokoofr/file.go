@@ -0,0 +1,352 @@
+package aferokoofr
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+// file is a folder, a file opened for reading, or, if temp is set, a file
+// opened for writing that is buffered in temp.
+type file struct {
+	fs      *Fs
+	name    string
+	path    string
+	info    koofrclient.FileInfo
+	reader  *koofrclient.FileReader
+	entries []os.FileInfo
+	listed  bool
+	temp    *os.File
+	dirty   bool
+	closed  bool
+}
+
+// create opens p for writing. The parent folder must exist. Unless the file
+// is truncated, its current content is downloaded into the buffer first.
+func (fs *Fs) create(name string, p string, flag int) (f *file, err error) {
+	if p == "/" {
+		return nil, koofrclient.PathError("open", name, ErrIsDir)
+	}
+
+	parent, err := fs.client.FilesInfo(fs.mountId, path.Dir(p))
+
+	if err != nil {
+		return nil, koofrclient.PathError("open", name, err)
+	}
+
+	if parent.Type != "dir" {
+		return nil, koofrclient.PathError("open", name, os.ErrNotExist)
+	}
+
+	info, err := fs.client.FilesInfo(fs.mountId, p)
+
+	exists := err == nil
+
+	if err != nil && !koofrclient.IsNotFound(err) {
+		return nil, koofrclient.PathError("open", name, err)
+	}
+
+	switch {
+	case exists && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, koofrclient.PathError("open", name, os.ErrExist)
+	case !exists && flag&os.O_CREATE == 0:
+		return nil, koofrclient.PathError("open", name, os.ErrNotExist)
+	case exists && info.Type == "dir":
+		return nil, koofrclient.PathError("open", name, ErrIsDir)
+	}
+
+	if !exists {
+		info = koofrclient.FileInfo{Name: path.Base(p), Type: "file"}
+	}
+
+	temp, err := ioutil.TempFile(fs.TempDir, "koofr-")
+
+	if err != nil {
+		return
+	}
+
+	f = &file{
+		fs:    fs,
+		name:  name,
+		path:  p,
+		info:  info,
+		temp:  temp,
+		dirty: !exists || flag&os.O_TRUNC != 0,
+	}
+
+	if exists && flag&os.O_TRUNC == 0 && info.Size > 0 {
+		if err = f.download(); err != nil {
+			f.discard()
+			return nil, koofrclient.PathError("open", name, err)
+		}
+	}
+
+	if flag&os.O_APPEND != 0 {
+		if _, err = temp.Seek(0, io.SeekEnd); err != nil {
+			f.discard()
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func (f *file) download() (err error) {
+	reader, err := f.fs.client.FilesGet(f.fs.mountId, f.path)
+
+	if err != nil {
+		return
+	}
+
+	defer reader.Close()
+
+	if _, err = io.Copy(f.temp, reader); err != nil {
+		return
+	}
+
+	_, err = f.temp.Seek(0, io.SeekStart)
+
+	return
+}
+
+func (f *file) discard() {
+	f.temp.Close()
+	os.Remove(f.temp.Name())
+}
+
+func (f *file) Name() string {
+	return f.name
+}
+
+// Sync uploads the buffered content of a file opened for writing.
+func (f *file) Sync() (err error) {
+	if f.temp == nil || !f.dirty {
+		return nil
+	}
+
+	info, err := f.fs.client.FilesPutFile(f.fs.mountId, path.Dir(f.path), f.temp.Name(), &koofrclient.PutFileOptions{
+		PutOptions: koofrclient.PutOptions{
+			NoRename:       true,
+			ForceOverwrite: true,
+		},
+		Name:           path.Base(f.path),
+		IgnoreModified: true,
+	})
+
+	if err != nil {
+		return koofrclient.PathError("sync", f.name, err)
+	}
+
+	f.info = *info
+	f.dirty = false
+
+	return nil
+}
+
+// Close uploads a file opened for writing and removes its buffer. If the
+// upload fails, the buffer is kept and the error names it.
+func (f *file) Close() (err error) {
+	if f.closed {
+		return koofrclient.PathError("close", f.name, os.ErrClosed)
+	}
+
+	f.closed = true
+
+	if f.temp == nil {
+		return f.reader.Close()
+	}
+
+	if err = f.Sync(); err != nil {
+		f.temp.Close()
+
+		if pe, ok := err.(*os.PathError); ok {
+			err = pe.Err
+		}
+
+		return koofrclient.PathError("close", f.name, fmt.Errorf("Upload failed, content was kept in %s: %s", f.temp.Name(), err))
+	}
+
+	f.discard()
+
+	return
+}
+
+func (f *file) Read(p []byte) (n int, err error) {
+	switch {
+	case f.temp != nil:
+		return f.temp.Read(p)
+	case f.info.Type == "dir":
+		return 0, koofrclient.PathError("read", f.name, ErrIsDir)
+	}
+
+	n, err = f.reader.Read(p)
+
+	if err != nil && err != io.EOF {
+		err = koofrclient.PathError("read", f.name, err)
+	}
+
+	return
+}
+
+// ReadAt fetches exactly the requested range of a file opened for reading.
+func (f *file) ReadAt(p []byte, off int64) (n int, err error) {
+	switch {
+	case f.temp != nil:
+		return f.temp.ReadAt(p, off)
+	case f.info.Type == "dir":
+		return 0, koofrclient.PathError("read", f.name, ErrIsDir)
+	case off < 0:
+		return 0, koofrclient.PathError("read", f.name, os.ErrInvalid)
+	case off >= f.info.Size:
+		return 0, io.EOF
+	case len(p) == 0:
+		return 0, nil
+	}
+
+	end := off + int64(len(p)) - 1
+
+	if end >= f.info.Size {
+		end = f.info.Size - 1
+	}
+
+	reader, err := f.fs.client.FilesGetRange(f.fs.mountId, f.path, &koofrclient.FileSpan{Start: off, End: end})
+
+	if err != nil {
+		return 0, koofrclient.PathError("read", f.name, err)
+	}
+
+	defer reader.Close()
+
+	n, err = io.ReadFull(reader, p[:end-off+1])
+
+	if err != nil {
+		return n, koofrclient.PathError("read", f.name, err)
+	}
+
+	if n < len(p) {
+		err = io.EOF
+	}
+
+	return
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if f.temp != nil {
+		return f.temp.Seek(offset, whence)
+	}
+
+	offset, err := f.reader.Seek(offset, whence)
+
+	if err != nil {
+		return 0, koofrclient.PathError("seek", f.name, os.ErrInvalid)
+	}
+
+	return offset, nil
+}
+
+func (f *file) Write(p []byte) (n int, err error) {
+	if f.temp == nil {
+		return 0, koofrclient.PathError("write", f.name, os.ErrPermission)
+	}
+
+	f.dirty = true
+
+	return f.temp.Write(p)
+}
+
+func (f *file) WriteAt(p []byte, off int64) (n int, err error) {
+	if f.temp == nil {
+		return 0, koofrclient.PathError("write", f.name, os.ErrPermission)
+	}
+
+	f.dirty = true
+
+	return f.temp.WriteAt(p, off)
+}
+
+func (f *file) WriteString(s string) (n int, err error) {
+	return f.Write([]byte(s))
+}
+
+func (f *file) Truncate(size int64) error {
+	if f.temp == nil {
+		return koofrclient.PathError("truncate", f.name, os.ErrPermission)
+	}
+
+	f.dirty = true
+
+	return f.temp.Truncate(size)
+}
+
+// Readdir follows os.File.Readdir. The folder is listed on the first call.
+func (f *file) Readdir(count int) (infos []os.FileInfo, err error) {
+	if f.info.Type != "dir" {
+		return nil, koofrclient.PathError("readdir", f.name, ErrNotDir)
+	}
+
+	if !f.listed {
+		files, err := f.fs.client.FilesList(f.fs.mountId, f.path)
+
+		if err != nil {
+			return nil, koofrclient.PathError("readdir", f.name, err)
+		}
+
+		f.entries = make([]os.FileInfo, len(files))
+		for i, info := range files {
+			f.entries[i] = &koofrclient.OSFileInfo{Info: info}
+		}
+		f.listed = true
+	}
+
+	if count <= 0 {
+		infos, f.entries = f.entries, nil
+		return infos, nil
+	}
+
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+
+	infos, f.entries = f.entries[:count], f.entries[count:]
+
+	return infos, nil
+}
+
+func (f *file) Readdirnames(n int) (names []string, err error) {
+	infos, err := f.Readdir(n)
+
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+
+	return
+}
+
+// Stat returns the remote file info, or for a file being written the size
+// and time of its buffer.
+func (f *file) Stat() (os.FileInfo, error) {
+	if f.temp == nil || !f.dirty {
+		return &koofrclient.OSFileInfo{Info: f.info}, nil
+	}
+
+	stat, err := f.temp.Stat()
+
+	if err != nil {
+		return nil, err
+	}
+
+	info := f.info
+	info.Size = stat.Size()
+	info.Modified = stat.ModTime().UnixNano() / int64(time.Millisecond)
+
+	return &koofrclient.OSFileInfo{Info: info}, nil
+}
@@ -0,0 +1,267 @@
+// Package aferokoofr implements afero.Fs on top of a Koofr mount.
+//
+// Koofr stores whole files, so files opened for writing are buffered in a
+// local temporary file and uploaded on Sync and Close. Files opened for
+// reading fetch ranges as they are read. Modification times can only be set
+// by copying, so Chtimes copies the file with the new time and replaces the
+// original. Permissions and owners are not stored and Chmod and Chown do
+// nothing.
+package aferokoofr
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/koofr/go-httpclient"
+	koofrclient "github.com/koofr/go-koofrclient"
+	"github.com/spf13/afero"
+)
+
+var ErrIsDir = fmt.Errorf("Is a directory")
+var ErrNotDir = fmt.Errorf("Not a directory")
+var ErrNotEmpty = fmt.Errorf("Directory not empty")
+
+type Fs struct {
+	client  *koofrclient.KoofrClient
+	mountId string
+	// TempDir is where files opened for writing are buffered. Defaults to
+	// os.TempDir().
+	TempDir string
+}
+
+func NewFs(client *koofrclient.KoofrClient, mountId string) *Fs {
+	return &Fs{
+		client:  client,
+		mountId: mountId,
+	}
+}
+
+func (fs *Fs) Name() string {
+	return "KoofrFs"
+}
+
+func (fs *Fs) Create(name string) (afero.File, error) {
+	return fs.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (fs *Fs) Open(name string) (afero.File, error) {
+	return fs.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (fs *Fs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	p := koofrclient.CleanPath(name)
+
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		return fs.create(name, p, flag)
+	}
+
+	info, err := fs.client.FilesInfo(fs.mountId, p)
+
+	if err != nil {
+		return nil, koofrclient.PathError("open", name, err)
+	}
+
+	return &file{
+		fs:     fs,
+		name:   name,
+		path:   p,
+		info:   info,
+		reader: fs.client.NewFileReader(fs.mountId, p, info.Size),
+	}, nil
+}
+
+func (fs *Fs) Mkdir(name string, perm os.FileMode) error {
+	p := koofrclient.CleanPath(name)
+
+	if p == "/" {
+		return koofrclient.PathError("mkdir", name, os.ErrExist)
+	}
+
+	err := fs.client.FilesNewFolder(fs.mountId, path.Dir(p), path.Base(p))
+
+	return koofrclient.PathError("mkdir", name, err)
+}
+
+// MkdirAll creates the folder and any missing parents. Folders that already
+// exist are not an error.
+func (fs *Fs) MkdirAll(name string, perm os.FileMode) error {
+	err := fs.client.FilesMkdirAll(fs.mountId, koofrclient.CleanPath(name))
+
+	if _, ok := err.(*koofrclient.NotDirError); ok {
+		err = ErrNotDir
+	}
+
+	return koofrclient.PathError("mkdir", name, err)
+}
+
+// Remove deletes a file or an empty folder.
+func (fs *Fs) Remove(name string) error {
+	p := koofrclient.CleanPath(name)
+
+	if p == "/" {
+		return koofrclient.PathError("remove", name, os.ErrPermission)
+	}
+
+	info, err := fs.client.FilesInfo(fs.mountId, p)
+
+	if err != nil {
+		return koofrclient.PathError("remove", name, err)
+	}
+
+	deleteOptions := &koofrclient.DeleteOptions{}
+
+	if info.Type == "dir" {
+		deleteOptions.RemoveIfEmpty = true
+	} else {
+		deleteOptions.RemoveIfHash = &info.Hash
+	}
+
+	err = fs.client.FilesDeleteWithOptions(fs.mountId, p, deleteOptions)
+
+	if err == koofrclient.ErrCannotRemove && info.Type == "dir" {
+		err = ErrNotEmpty
+	}
+
+	return koofrclient.PathError("remove", name, err)
+}
+
+// RemoveAll deletes a file or a folder with its content. The mount root can
+// not be removed.
+func (fs *Fs) RemoveAll(name string) error {
+	p := koofrclient.CleanPath(name)
+
+	if p == "/" {
+		return koofrclient.PathError("remove", name, os.ErrPermission)
+	}
+
+	err := fs.client.FilesDelete(fs.mountId, p)
+
+	if koofrclient.IsNotFound(err) {
+		return nil
+	}
+
+	return koofrclient.PathError("remove", name, err)
+}
+
+// Rename moves a file or folder. Like os.Rename, an existing file at newname
+// is replaced.
+func (fs *Fs) Rename(oldname string, newname string) error {
+	oldPath, newPath := koofrclient.CleanPath(oldname), koofrclient.CleanPath(newname)
+
+	if oldPath == "/" || newPath == "/" {
+		return koofrclient.PathError("rename", oldname, os.ErrPermission)
+	}
+
+	if oldPath == newPath {
+		return nil
+	}
+
+	err := fs.client.FilesMove(fs.mountId, oldPath, fs.mountId, newPath)
+
+	if httpclient.IsInvalidStatusCode(err, http.StatusConflict) {
+		var existing koofrclient.FileInfo
+
+		existing, err = fs.client.FilesInfo(fs.mountId, newPath)
+
+		if err == nil && existing.Type == "dir" {
+			err = os.ErrExist
+		}
+
+		if err == nil {
+			err = fs.replace(oldPath, newPath, existing.Hash)
+		}
+	}
+
+	return koofrclient.PathError("rename", oldname, err)
+}
+
+func (fs *Fs) Stat(name string) (os.FileInfo, error) {
+	info, err := fs.client.FilesInfo(fs.mountId, koofrclient.CleanPath(name))
+
+	if err != nil {
+		return nil, koofrclient.PathError("stat", name, err)
+	}
+
+	return &koofrclient.OSFileInfo{Info: info}, nil
+}
+
+func (fs *Fs) Chmod(name string, mode os.FileMode) error {
+	return nil
+}
+
+func (fs *Fs) Chown(name string, uid int, gid int) error {
+	return nil
+}
+
+// Chtimes sets the modified time of a file by copying it with the new time
+// next to the original and moving the copy over it with replace. The access
+// time is ignored, and folders are not supported.
+func (fs *Fs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	p := koofrclient.CleanPath(name)
+
+	info, err := fs.client.FilesInfo(fs.mountId, p)
+
+	if err != nil {
+		return koofrclient.PathError("chtimes", name, err)
+	}
+
+	if info.Type != "file" {
+		return koofrclient.PathError("chtimes", name, ErrIsDir)
+	}
+
+	modified := mtime.UnixNano() / int64(time.Millisecond)
+	tmpPath := tempPath(p, "chtimes")
+
+	err = fs.client.FilesCopy(fs.mountId, p, fs.mountId, tmpPath, koofrclient.CopyOptions{SetModified: &modified})
+
+	if err != nil {
+		return koofrclient.PathError("chtimes", name, err)
+	}
+
+	if err = fs.replace(tmpPath, p, info.Hash); err != nil {
+		fs.client.FilesDelete(fs.mountId, tmpPath)
+	}
+
+	return koofrclient.PathError("chtimes", name, err)
+}
+
+// replace moves src over the existing file dst, if dst still has the given
+// hash. dst is moved aside first and moved back if src can not take its
+// place, so a failure never leaves dst missing.
+func (fs *Fs) replace(src string, dst string, hash string) (err error) {
+	backup := tempPath(dst, "replaced")
+
+	if err = fs.client.FilesMove(fs.mountId, dst, fs.mountId, backup); err != nil {
+		return
+	}
+
+	info, err := fs.client.FilesInfo(fs.mountId, backup)
+
+	if err == nil && info.Hash != hash {
+		err = koofrclient.ErrCannotOverwrite
+	}
+
+	if err == nil {
+		err = fs.client.FilesMove(fs.mountId, src, fs.mountId, dst)
+	}
+
+	if err != nil {
+		if restoreErr := fs.client.FilesMove(fs.mountId, backup, fs.mountId, dst); restoreErr != nil {
+			return fmt.Errorf("%s (previous content was left at %s)", err, backup)
+		}
+
+		return
+	}
+
+	fs.client.FilesDelete(fs.mountId, backup)
+
+	return nil
+}
+
+// tempPath returns a hidden sibling path of p.
+func tempPath(p string, suffix string) string {
+	return path.Join(path.Dir(p), fmt.Sprintf(".%s.%d.%s", path.Base(p), time.Now().UnixNano(), suffix))
+}
@@ -0,0 +1,255 @@
+package aferokoofr_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/koofr/go-koofrclient/aferokoofr"
+	"github.com/koofr/go-koofrclient/koofrtest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Fs", func() {
+	var server *koofrtest.Server
+	var fs afero.Fs
+
+	BeforeEach(func() {
+		server = koofrtest.NewServer()
+		server.PutFile(koofrtest.PrimaryMountId, "/dir/file.txt", []byte("content"), 1562663291000)
+		fs = aferokoofr.NewFs(server.Client(), koofrtest.PrimaryMountId)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should stat files", func() {
+		fi, err := fs.Stat("/dir/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fi.Name()).To(Equal("file.txt"))
+		Expect(fi.Size()).To(Equal(int64(7)))
+		Expect(fi.ModTime().Unix()).To(Equal(int64(1562663291)))
+
+		_, err = fs.Stat("/missing")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should read files", func() {
+		data, err := afero.ReadFile(fs, "/dir/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("content"))
+
+		f, err := fs.Open("/dir/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+
+		buf := make([]byte, 4)
+		n, err := f.ReadAt(buf, 5)
+		Expect(err).To(Equal(io.EOF))
+		Expect(string(buf[:n])).To(Equal("nt"))
+	})
+
+	It("should upload files on close", func() {
+		f, err := fs.Create("/dir/new.txt")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.WriteString("new content")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.WriteAt([]byte("N"), 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, ok := server.ReadFile(koofrtest.PrimaryMountId, "/dir/new.txt")
+		Expect(ok).To(BeFalse())
+
+		Expect(f.Close()).To(Succeed())
+
+		data, _ := server.ReadFile(koofrtest.PrimaryMountId, "/dir/new.txt")
+		Expect(string(data)).To(Equal("New content"))
+	})
+
+	It("should append to existing files", func() {
+		f, err := fs.OpenFile("/dir/file.txt", os.O_WRONLY|os.O_APPEND, 0644)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.WriteString(" appended")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		data, _ := server.ReadFile(koofrtest.PrimaryMountId, "/dir/file.txt")
+		Expect(string(data)).To(Equal("content appended"))
+	})
+
+	It("should fail to create files in missing folders", func() {
+		_, err := fs.Create("/missing/file.txt")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should create folders", func() {
+		Expect(fs.Mkdir("/a", 0755)).To(Succeed())
+		Expect(os.IsExist(fs.Mkdir("/a", 0755))).To(BeTrue())
+		Expect(fs.MkdirAll("/a/b/c", 0755)).To(Succeed())
+		Expect(fs.MkdirAll("/a/b/c", 0755)).To(Succeed())
+		err := fs.MkdirAll("/dir/file.txt/x", 0755)
+		Expect(err.(*os.PathError).Err).To(Equal(aferokoofr.ErrNotDir))
+
+		fi, err := fs.Stat("/a/b/c")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fi.IsDir()).To(BeTrue())
+	})
+
+	It("should create overlapping folders concurrently", func() {
+		var wg sync.WaitGroup
+		errs := make(chan error, 8)
+
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+				errs <- fs.MkdirAll(fmt.Sprintf("/x/y/z%d", i%2), 0755)
+			}(i)
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		fi, err := fs.Stat("/x/y/z1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fi.IsDir()).To(BeTrue())
+	})
+
+	It("should list folders", func() {
+		server.PutFile(koofrtest.PrimaryMountId, "/dir/sub/other.txt", []byte("x"), 1000)
+
+		var paths []string
+		err := afero.Walk(fs, "/", func(p string, info os.FileInfo, err error) error {
+			paths = append(paths, p)
+			return err
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{"/", "/dir", "/dir/file.txt", "/dir/sub", "/dir/sub/other.txt"}))
+	})
+
+	It("should remove only empty folders", func() {
+		err := fs.Remove("/dir")
+		Expect(err).To(HaveOccurred())
+		Expect(fs.Remove("/dir/file.txt")).To(Succeed())
+		Expect(fs.Remove("/dir")).To(Succeed())
+		Expect(os.IsNotExist(fs.Remove("/dir"))).To(BeTrue())
+	})
+
+	It("should remove folders with their content", func() {
+		Expect(fs.RemoveAll("/dir")).To(Succeed())
+		Expect(fs.RemoveAll("/dir")).To(Succeed())
+		Expect(fs.RemoveAll("/")).To(HaveOccurred())
+	})
+
+	It("should rename over existing files", func() {
+		server.PutFile(koofrtest.PrimaryMountId, "/other.txt", []byte("other"), 1000)
+
+		Expect(fs.Rename("/other.txt", "/dir/file.txt")).To(Succeed())
+
+		data, _ := server.ReadFile(koofrtest.PrimaryMountId, "/dir/file.txt")
+		Expect(string(data)).To(Equal("other"))
+		_, ok := server.ReadFile(koofrtest.PrimaryMountId, "/other.txt")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should set the modified time", func() {
+		mtime := time.Unix(1600000000, 0)
+		Expect(fs.Chtimes("/dir/file.txt", mtime, mtime)).To(Succeed())
+
+		fi, err := fs.Stat("/dir/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fi.ModTime().Equal(mtime)).To(BeTrue())
+
+		data, _ := server.ReadFile(koofrtest.PrimaryMountId, "/dir/file.txt")
+		Expect(string(data)).To(Equal("content"))
+
+		infos, err := afero.ReadDir(fs, "/dir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(infos).To(HaveLen(1))
+	})
+	It("should keep the target when a rename fails", func() {
+		server.PutFile(koofrtest.PrimaryMountId, "/dir/other.txt", []byte("other"), 1000)
+		moves := 0
+		server.FailFunc(func(op string, p string) int {
+			if op == "move" && p == "/dir/other.txt" {
+				// the first attempt conflicts, the one after moving the
+				// target aside fails
+				if moves++; moves == 2 {
+					return http.StatusInternalServerError
+				}
+			}
+			return 0
+		})
+
+		Expect(fs.Rename("/dir/other.txt", "/dir/file.txt")).NotTo(Succeed())
+
+		data, _ := server.ReadFile(koofrtest.PrimaryMountId, "/dir/file.txt")
+		Expect(string(data)).To(Equal("content"))
+		data, _ = server.ReadFile(koofrtest.PrimaryMountId, "/dir/other.txt")
+		Expect(string(data)).To(Equal("other"))
+
+		names, err := afero.ReadDir(fs, "/dir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(HaveLen(2))
+	})
+
+	It("should keep the file when setting the modified time fails", func() {
+		server.FailFunc(func(op string, p string) int {
+			if op == "move" && strings.HasSuffix(p, ".chtimes") {
+				return http.StatusInternalServerError
+			}
+			return 0
+		})
+
+		mtime := time.Unix(1600000000, 0)
+		Expect(fs.Chtimes("/dir/file.txt", mtime, mtime)).NotTo(Succeed())
+
+		fi, err := fs.Stat("/dir/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fi.ModTime().Unix()).To(Equal(int64(1562663291)))
+
+		infos, err := afero.ReadDir(fs, "/dir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(infos).To(HaveLen(1))
+	})
+
+	It("should keep the buffer when the upload on close fails", func() {
+		tempDir, err := ioutil.TempDir("", "aferokoofr")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(tempDir)
+
+		kfs := aferokoofr.NewFs(server.Client(), koofrtest.PrimaryMountId)
+		kfs.TempDir = tempDir
+
+		f, err := kfs.Create("/dir/new.txt")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.WriteString("new content")
+		Expect(err).NotTo(HaveOccurred())
+
+		server.FailWith("put", http.StatusInternalServerError)
+
+		err = f.Close()
+		Expect(err).To(HaveOccurred())
+
+		buffers, _ := filepath.Glob(filepath.Join(tempDir, "*"))
+		Expect(buffers).To(HaveLen(1))
+		Expect(err.Error()).To(ContainSubstring(buffers[0]))
+
+		data, _ := ioutil.ReadFile(buffers[0])
+		Expect(string(data)).To(Equal("new content"))
+	})
+})
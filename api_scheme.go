@@ -1,6 +1,7 @@
 package koofrclient
 
 import (
+	"io"
 	"path"
 )
 
@@ -38,6 +39,13 @@ type Mount struct {
 	IsShared    bool             `json:"isShared"`
 }
 
+func (m *Mount) SpaceFree() int64 {
+	if m.SpaceUsed >= m.SpaceTotal {
+		return 0
+	}
+	return m.SpaceTotal - m.SpaceUsed
+}
+
 type MountUser struct {
 	Id          string           `json:"id"`
 	Name        string           `json:"name"`
@@ -114,6 +122,37 @@ type FileSpan struct {
 	End   int64
 }
 
+// FileContent is a streamed response body with its metadata. Close must be
+// called when done reading.
+type FileContent struct {
+	io.ReadCloser
+	ContentType string
+	// ContentLength is the length of the body, -1 if unknown.
+	ContentLength int64
+	// Span is the part of the file in the body, nil if the body is the
+	// whole file.
+	Span *FileSpan
+	// Size is the total file size taken from Content-Range, or the
+	// Content-Length of a whole file response. -1 if unknown.
+	Size int64
+	// Name is the file name from Content-Disposition, if any.
+	Name string
+	// Hash is the file hash sent as ETag, if any.
+	Hash string
+	// Modified is the Last-Modified time in milliseconds, 0 if unknown.
+	Modified int64
+}
+
+type GetOptions struct {
+	Span *FileSpan
+	// IfNoneMatch is a file hash. If the file still has it, ErrNotModified
+	// is returned instead of the content.
+	IfNoneMatch *string
+	// IfModifiedSince is a time in milliseconds. If the file was not
+	// modified after it, ErrNotModified is returned instead of the content.
+	IfModifiedSince *int64
+}
+
 type FileUpload struct {
 	Name string `json:"name"`
 }
@@ -126,6 +165,14 @@ type PutOptions struct {
 	NoRename                   bool
 	ForceOverwrite             bool
 	SetModified                *int64
+	// ContentLength is the size of the uploaded content. If nil, it is
+	// taken from readers that report their size (bytes.Reader, os.File, ...).
+	ContentLength *int64
+	// CheckSpace makes the upload fail with ErrInsufficientSpace before
+	// sending any content if the content length is known and exceeds the
+	// free space of the mount. The check is skipped when ContentLength is
+	// nil and the size cannot be taken from the reader.
+	CheckSpace bool
 }
 
 type CopyOptions struct {
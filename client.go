@@ -1,6 +1,7 @@
 package koofrclient
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -12,6 +13,8 @@ type KoofrClient struct {
 	*httpclient.HTTPClient
 	token  string
 	userID string
+	ctx    context.Context
+	hooks  []RequestHook
 }
 
 func NewKoofrClient(baseUrl string, disableSecurity bool) *KoofrClient {
@@ -41,6 +44,34 @@ func NewKoofrClientWithHTTPClient(baseUrl string, httpClient *httpclient.HTTPCli
 	return client
 }
 
+// WithContext returns a copy of the client that sends all requests with ctx,
+// so they are aborted when ctx is canceled. The copy shares the underlying
+// HTTP client, token and headers with c.
+func (c *KoofrClient) WithContext(ctx context.Context) *KoofrClient {
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+func (c *KoofrClient) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+func (c *KoofrClient) Request(request *httpclient.RequestData) (res *http.Response, err error) {
+	if request.Context == nil && c.ctx != nil {
+		request.Context = c.ctx
+	}
+
+	if len(c.hooks) > 0 {
+		return c.hookedRequest(request)
+	}
+
+	return c.HTTPClient.Request(request)
+}
+
 func (c *KoofrClient) SetUserAgent(ua string) {
 	c.Headers.Set("User-Agent", ua)
 }
@@ -5,13 +5,17 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"time"
 
 	"github.com/koofr/go-httpclient"
 )
 
 var ErrCannotOverwrite = fmt.Errorf("Can not overwrite (filter constraint fails)")
 var ErrCannotRemove = fmt.Errorf("Can not remove (filter constraint fails)")
+var ErrInsufficientSpace = fmt.Errorf("Not enough free space on mount")
+var ErrNotModified = fmt.Errorf("Not modified")
 
 func (c *KoofrClient) FilesInfo(mountId string, path string) (info FileInfo, err error) {
 	params := url.Values{}
@@ -195,6 +199,22 @@ func (c *KoofrClient) FilesMove(mountId string, path string, toMountId string, t
 }
 
 func (c *KoofrClient) FilesGetRange(mountId string, path string, span *FileSpan) (reader io.ReadCloser, err error) {
+	content, err := c.FilesGetWithOptions(mountId, path, &GetOptions{Span: span})
+
+	if err != nil {
+		return
+	}
+
+	reader = content
+
+	return
+}
+
+// FilesGetWithOptions returns the file content, or the Span of it, with the
+// metadata from the response headers, so no separate FilesInfo call is
+// needed to describe it. With IfNoneMatch or IfModifiedSince set, it returns
+// ErrNotModified when the file did not change.
+func (c *KoofrClient) FilesGetWithOptions(mountId string, path string, getOptions *GetOptions) (content *FileContent, err error) {
 	params := url.Values{}
 	params.Set("path", path)
 
@@ -203,14 +223,23 @@ func (c *KoofrClient) FilesGetRange(mountId string, path string, span *FileSpan)
 		Path:           "/content/api/v2/mounts/" + mountId + "/files/get",
 		Params:         params,
 		Headers:        make(http.Header),
-		ExpectedStatus: []int{http.StatusOK, http.StatusPartialContent},
+		ExpectedStatus: []int{http.StatusOK, http.StatusPartialContent, http.StatusNotModified},
 	}
 
-	if span != nil {
-		if span.End == -1 {
-			request.Headers.Set("Range", fmt.Sprintf("bytes=%d-", span.Start))
-		} else {
-			request.Headers.Set("Range", fmt.Sprintf("bytes=%d-%d", span.Start, span.End))
+	if getOptions != nil {
+		if span := getOptions.Span; span != nil {
+			if span.End == -1 {
+				request.Headers.Set("Range", fmt.Sprintf("bytes=%d-", span.Start))
+			} else {
+				request.Headers.Set("Range", fmt.Sprintf("bytes=%d-%d", span.Start, span.End))
+			}
+		}
+		if getOptions.IfNoneMatch != nil {
+			request.Headers.Set("If-None-Match", fmt.Sprintf(`"%s"`, *getOptions.IfNoneMatch))
+		}
+		if getOptions.IfModifiedSince != nil {
+			since := time.Unix(0, *getOptions.IfModifiedSince*int64(time.Millisecond))
+			request.Headers.Set("If-Modified-Since", since.UTC().Format(http.TimeFormat))
 		}
 	}
 
@@ -220,7 +249,12 @@ func (c *KoofrClient) FilesGetRange(mountId string, path string, span *FileSpan)
 		return
 	}
 
-	reader = res.Body
+	if res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+		return nil, ErrNotModified
+	}
+
+	content = newFileContent(res)
 
 	return
 }
@@ -231,10 +265,29 @@ func (c *KoofrClient) FilesGet(mountId string, path string) (reader io.ReadClose
 
 func (c *KoofrClient) FilesPut(mountId string, path string, name string, reader io.Reader) (newName string, err error) {
 	info, err := c.FilesPutWithOptions(mountId, path, name, reader, nil)
-	return info.Name, err
+
+	if err != nil {
+		return
+	}
+
+	return info.Name, nil
 }
 
 func (c *KoofrClient) FilesPutWithOptions(mountId string, path string, name string, reader io.Reader, putOptions *PutOptions) (fileInfo *FileInfo, err error) {
+	return c.filesPut(mountId, path, name, reader, putOptions, func(request *httpclient.RequestData) error {
+		return request.UploadFile("file", "dummy", reader)
+	})
+}
+
+// filesPut uploads the request body set by upload. reader is only used to
+// determine the content size for the free space check.
+func (c *KoofrClient) filesPut(mountId string, path string, name string, reader io.Reader, putOptions *PutOptions, upload func(request *httpclient.RequestData) error) (fileInfo *FileInfo, err error) {
+	if putOptions != nil && putOptions.CheckSpace {
+		if err = c.checkSpace(mountId, reader, putOptions.ContentLength); err != nil {
+			return nil, err
+		}
+	}
+
 	params := url.Values{}
 	params.Set("path", path)
 	params.Set("filename", name)
@@ -276,7 +329,7 @@ func (c *KoofrClient) FilesPutWithOptions(mountId string, path string, name stri
 		RespValue:      &fileInfo,
 	}
 
-	err = request.UploadFile("file", "dummy", reader)
+	err = upload(&request)
 
 	if err != nil {
 		return
@@ -291,6 +344,9 @@ func (c *KoofrClient) FilesPutWithOptions(mountId string, path string, name stri
 			if err.Got == http.StatusConflict {
 				return nil, ErrCannotOverwrite
 			}
+			if err.Got == http.StatusInsufficientStorage {
+				return nil, ErrInsufficientSpace
+			}
 		default:
 			return nil, err
 		}
@@ -298,3 +354,53 @@ func (c *KoofrClient) FilesPutWithOptions(mountId string, path string, name stri
 
 	return
 }
+
+// checkSpace fails with ErrInsufficientSpace if the content is larger than
+// the free space of the mount. Uploads of unknown size are not checked, and
+// the size of a file being overwritten is not taken into account.
+func (c *KoofrClient) checkSpace(mountId string, reader io.Reader, contentLength *int64) (err error) {
+	size, ok := int64(0), false
+
+	if contentLength != nil {
+		size, ok = *contentLength, true
+	} else {
+		size, ok = readerSize(reader)
+	}
+
+	if !ok {
+		return nil
+	}
+
+	mount, err := c.MountsDetails(mountId)
+
+	if err != nil {
+		return
+	}
+
+	if mount.SpaceTotal > 0 && size > mount.SpaceFree() {
+		return ErrInsufficientSpace
+	}
+
+	return nil
+}
+
+// readerSize returns the number of bytes left in reader if it can tell
+// without reading.
+func readerSize(reader io.Reader) (size int64, ok bool) {
+	switch r := reader.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len()), true
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+		return info.Size() - offset, true
+	}
+
+	return 0, false
+}
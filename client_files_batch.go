@@ -0,0 +1,176 @@
+package koofrclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+const DefaultBatchConcurrency = 4
+
+// ErrBatchSkipped is the result of operations that were not run because an
+// earlier operation failed with StopOnError set.
+var ErrBatchSkipped = fmt.Errorf("Operation skipped")
+
+// BatchOptions control FilesCopyMany, FilesMoveMany and FilesDeleteMany.
+//
+// Every item is a separate request because the v2 API has no bulk copy,
+// move or delete endpoint; files/copy, files/move and files/remove take a
+// single path. Running them concurrently is what keeps large batches fast,
+// and it also keeps a status per item and per-item conditions such as
+// DeleteOptions.RemoveIfHash, which a bulk call could not report.
+type BatchOptions struct {
+	// Context cancels the batch. Operations not yet started get the context
+	// error as their result and running requests are aborted.
+	Context context.Context
+	// Concurrency is the number of operations run in parallel. Defaults to
+	// DefaultBatchConcurrency.
+	Concurrency int
+	// StopOnError skips all remaining operations after the first failure.
+	StopOnError bool
+}
+
+type BatchCopy struct {
+	MountId   string
+	Path      string
+	ToMountId string
+	ToPath    string
+	Options   CopyOptions
+}
+
+type BatchMove struct {
+	MountId   string
+	Path      string
+	ToMountId string
+	ToPath    string
+}
+
+type BatchDelete struct {
+	MountId string
+	Path    string
+	Options *DeleteOptions
+}
+
+// BatchResult is the outcome of the operation at Index in the batch. Err is
+// nil on success.
+type BatchResult struct {
+	Index int
+	Err   error
+}
+
+// BatchError is returned when at least one operation in a batch did not
+// succeed.
+type BatchError struct {
+	Results []BatchResult
+}
+
+func (e *BatchError) Failed() []BatchResult {
+	failed := []BatchResult{}
+
+	for _, result := range e.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+func (e *BatchError) Error() string {
+	failed := e.Failed()
+	return fmt.Sprintf("%d of %d operations failed, first error: %s", len(failed), len(e.Results), failed[0].Err)
+}
+
+// FilesCopyMany copies every item, see BatchOptions.
+func (c *KoofrClient) FilesCopyMany(items []BatchCopy, options *BatchOptions) (results []BatchResult, err error) {
+	return c.runBatch(len(items), options, func(client *KoofrClient, i int) error {
+		item := items[i]
+		return client.FilesCopy(item.MountId, item.Path, item.ToMountId, item.ToPath, item.Options)
+	})
+}
+
+// FilesMoveMany moves every item, see BatchOptions.
+func (c *KoofrClient) FilesMoveMany(items []BatchMove, options *BatchOptions) (results []BatchResult, err error) {
+	return c.runBatch(len(items), options, func(client *KoofrClient, i int) error {
+		item := items[i]
+		return client.FilesMove(item.MountId, item.Path, item.ToMountId, item.ToPath)
+	})
+}
+
+// FilesDeleteMany deletes every item with its own DeleteOptions, see
+// BatchOptions.
+func (c *KoofrClient) FilesDeleteMany(items []BatchDelete, options *BatchOptions) (results []BatchResult, err error) {
+	return c.runBatch(len(items), options, func(client *KoofrClient, i int) error {
+		item := items[i]
+		return client.filesDelete(item.MountId, item.Path, item.Options)
+	})
+}
+
+// runBatch runs op for indexes 0 to n-1 on a bounded number of goroutines.
+// It returns a result for every index, and a *BatchError if any failed.
+func (c *KoofrClient) runBatch(n int, options *BatchOptions, op func(client *KoofrClient, i int) error) (results []BatchResult, err error) {
+	if options == nil {
+		options = &BatchOptions{}
+	}
+
+	ctx := options.Context
+	if ctx == nil {
+		ctx = c.Context()
+	}
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	client := c.WithContext(ctx)
+
+	results = make([]BatchResult, n)
+
+	var failed int32
+	var wg sync.WaitGroup
+
+	sem := make(chan struct{}, concurrency)
+
+	for i := 0; i < n; i++ {
+		results[i].Index = i
+
+		select {
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		if options.StopOnError && atomic.LoadInt32(&failed) > 0 {
+			<-sem
+			results[i].Err = ErrBatchSkipped
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := op(client, i); err != nil {
+				results[i].Err = err
+				atomic.AddInt32(&failed, 1)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Err != nil {
+			return results, &BatchError{results}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,79 @@
+package koofrclient_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientFilesBatch", func() {
+	BeforeEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/batch")
+		err := client.FilesNewFolder(defaultMountId, rootPath, "batch")
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 5; i++ {
+			_, err := client.FilesPut(defaultMountId, rootPath+"/batch", fmt.Sprintf("%d.txt", i), bytes.NewReader([]byte("content")))
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/batch")
+	})
+
+	It("should copy, move and delete many files", func() {
+		copies := []k.BatchCopy{}
+		moves := []k.BatchMove{}
+		deletes := []k.BatchDelete{}
+		for i := 0; i < 5; i++ {
+			p := fmt.Sprintf("%s/batch/%d.txt", rootPath, i)
+			copies = append(copies, k.BatchCopy{MountId: defaultMountId, Path: p, ToMountId: defaultMountId, ToPath: p + ".copy"})
+			moves = append(moves, k.BatchMove{MountId: defaultMountId, Path: p + ".copy", ToMountId: defaultMountId, ToPath: p + ".moved"})
+			deletes = append(deletes, k.BatchDelete{MountId: defaultMountId, Path: p + ".moved"})
+		}
+
+		results, err := client.FilesCopyMany(copies, &k.BatchOptions{Concurrency: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(5))
+
+		_, err = client.FilesMoveMany(moves, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.FilesDeleteMany(deletes, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		files, err := client.FilesList(defaultMountId, rootPath+"/batch")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(5))
+	})
+
+	It("should report partial failures", func() {
+		wrongSize := int64(1)
+		deletes := []k.BatchDelete{
+			{MountId: defaultMountId, Path: rootPath + "/batch/0.txt"},
+			{MountId: defaultMountId, Path: rootPath + "/batch/1.txt", Options: &k.DeleteOptions{RemoveIfSize: &wrongSize}},
+		}
+
+		results, err := client.FilesDeleteMany(deletes, nil)
+		Expect(err).To(HaveOccurred())
+		batchErr, ok := err.(*k.BatchError)
+		Expect(ok).To(BeTrue())
+		Expect(batchErr.Failed()).To(HaveLen(1))
+		Expect(results[0].Err).NotTo(HaveOccurred())
+		Expect(results[1].Err).To(Equal(k.ErrCannotRemove))
+	})
+
+	It("should not run operations after cancellation", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		results, err := client.FilesDeleteMany([]k.BatchDelete{{MountId: defaultMountId, Path: rootPath + "/batch/0.txt"}}, &k.BatchOptions{Context: ctx})
+		Expect(err).To(HaveOccurred())
+		Expect(results[0].Err).To(Equal(context.Canceled))
+		_, err = client.FilesInfo(defaultMountId, rootPath+"/batch/0.txt")
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
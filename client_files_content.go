@@ -0,0 +1,86 @@
+package koofrclient
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilesGetWithInfo returns the file content, or the span of it if span is
+// not nil, with its metadata. It is FilesGetWithOptions with only Span set.
+func (c *KoofrClient) FilesGetWithInfo(mountId string, path string, span *FileSpan) (content *FileContent, err error) {
+	return c.FilesGetWithOptions(mountId, path, &GetOptions{Span: span})
+}
+
+func newFileContent(res *http.Response) *FileContent {
+	content := &FileContent{
+		ReadCloser:    res.Body,
+		ContentType:   res.Header.Get("Content-Type"),
+		ContentLength: res.ContentLength,
+		Size:          -1,
+	}
+
+	if res.StatusCode == http.StatusPartialContent {
+		content.Span, content.Size = parseContentRange(res.Header.Get("Content-Range"))
+	} else {
+		content.Size = res.ContentLength
+	}
+
+	if _, params, err := mime.ParseMediaType(res.Header.Get("Content-Disposition")); err == nil {
+		content.Name = params["filename"]
+	}
+
+	if etag := res.Header.Get("ETag"); etag != "" {
+		content.Hash = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	}
+
+	if modified, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+		content.Modified = modified.UnixNano() / int64(time.Millisecond)
+	}
+
+	return content
+}
+
+// parseContentRange parses a "bytes start-end/size" header. Parts that are
+// missing or unknown ("*") are returned as nil and -1.
+func parseContentRange(value string) (span *FileSpan, size int64) {
+	size = -1
+
+	if !strings.HasPrefix(value, "bytes ") {
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, "bytes "), "/", 2)
+
+	if len(parts) != 2 {
+		return
+	}
+
+	if n, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+		size = n
+	}
+
+	bounds := strings.SplitN(parts[0], "-", 2)
+
+	if len(bounds) != 2 {
+		return
+	}
+
+	start, err := strconv.ParseInt(bounds[0], 10, 64)
+
+	if err != nil {
+		return
+	}
+
+	end, err := strconv.ParseInt(bounds[1], 10, 64)
+
+	if err != nil {
+		return
+	}
+
+	span = &FileSpan{Start: start, End: end}
+
+	return
+}
@@ -0,0 +1,49 @@
+package koofrclient_test
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientFilesContent", func() {
+	BeforeEach(func() {
+		_, err := client.FilesPutWithOptions(defaultMountId, rootPath, "file.txt", bytes.NewReader([]byte("content")), &koofrclient.PutOptions{ForceOverwrite: true})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/file.txt")
+	})
+
+	It("should get a file with its metadata", func() {
+		info, err := client.FilesInfo(defaultMountId, rootPath+"/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+
+		content, err := client.FilesGetWithInfo(defaultMountId, rootPath+"/file.txt", nil)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := ioutil.ReadAll(content)
+		Expect(err).NotTo(HaveOccurred())
+		content.Close()
+		Expect(data).To(Equal([]byte("content")))
+		Expect(content.Span).To(BeNil())
+		Expect(content.Size).To(Equal(int64(7)))
+		Expect(content.ContentType).NotTo(BeEmpty())
+		Expect(content.Hash).To(Equal(info.Hash))
+	})
+
+	It("should get a file span with the total size", func() {
+		content, err := client.FilesGetWithInfo(defaultMountId, rootPath+"/file.txt", &koofrclient.FileSpan{Start: 2, End: 3})
+		Expect(err).NotTo(HaveOccurred())
+		data, err := ioutil.ReadAll(content)
+		Expect(err).NotTo(HaveOccurred())
+		content.Close()
+		Expect(data).To(Equal([]byte("nt")))
+		Expect(content.ContentLength).To(Equal(int64(2)))
+		Expect(content.Span).To(Equal(&koofrclient.FileSpan{Start: 2, End: 3}))
+		Expect(content.Size).To(Equal(int64(7)))
+	})
+})
@@ -0,0 +1,196 @@
+package koofrclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const PartialDownloadSuffix = ".koofrpart"
+
+var ErrDownloadSizeMismatch = fmt.Errorf("Downloaded size does not match file size")
+
+type DownloadFileOptions struct {
+	// NoResume discards a partial download left by an earlier call.
+	NoResume bool
+	// IgnoreModified leaves the local modified time at the download time
+	// instead of setting it to the remote one.
+	IgnoreModified bool
+}
+
+// partialDownload is stored next to the partial file and identifies the
+// remote file version it belongs to.
+type partialDownload struct {
+	Hash     string `json:"hash"`
+	Modified int64  `json:"modified"`
+	Size     int64  `json:"size"`
+}
+
+// FilesDownloadFile downloads a file to localPath. Content is written to
+// localPath+PartialDownloadSuffix, synced and renamed into place only when
+// complete, so localPath never holds a partial file. An interrupted download
+// is kept and resumed by the next call if the remote hash, modified time and
+// size did not change in the meantime.
+func (c *KoofrClient) FilesDownloadFile(mountId string, path string, localPath string, options *DownloadFileOptions) (info FileInfo, err error) {
+	opts := DownloadFileOptions{}
+
+	if options != nil {
+		opts = *options
+	}
+
+	info, err = c.FilesInfo(mountId, path)
+
+	if err != nil {
+		return
+	}
+
+	if info.Type != "file" {
+		return info, fmt.Errorf("Not a file: %s", path)
+	}
+
+	partPath := localPath + PartialDownloadSuffix
+	metaPath := partPath + ".json"
+
+	expected := partialDownload{
+		Hash:     info.Hash,
+		Modified: info.Modified,
+		Size:     info.Size,
+	}
+
+	offset := int64(0)
+
+	if !opts.NoResume {
+		offset = resumeOffset(partPath, metaPath, expected)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0644)
+
+	if err != nil {
+		return
+	}
+
+	if offset == 0 {
+		if err = writePartialDownload(metaPath, expected); err != nil {
+			f.Close()
+			return
+		}
+	}
+
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return
+	}
+
+	if offset < info.Size {
+		var reader io.ReadCloser
+
+		reader, err = c.FilesGetRange(mountId, path, &FileSpan{Start: offset, End: -1})
+
+		if err != nil {
+			f.Close()
+			return
+		}
+
+		_, err = io.Copy(f, reader)
+
+		reader.Close()
+
+		if err != nil {
+			f.Close()
+			return
+		}
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return
+	}
+
+	stat, err := f.Stat()
+
+	f.Close()
+
+	if err != nil {
+		return
+	}
+
+	if stat.Size() != info.Size {
+		os.Remove(partPath)
+		os.Remove(metaPath)
+		return info, ErrDownloadSizeMismatch
+	}
+
+	if !opts.IgnoreModified && info.Modified > 0 {
+		modified := time.Unix(0, info.Modified*int64(time.Millisecond))
+
+		if err = os.Chtimes(partPath, modified, modified); err != nil {
+			return
+		}
+	}
+
+	if err = os.Rename(partPath, localPath); err != nil {
+		return
+	}
+
+	os.Remove(metaPath)
+
+	syncDir(filepath.Dir(localPath))
+
+	return
+}
+
+// resumeOffset returns the size of a partial download that belongs to the
+// expected file version, or 0 if there is none.
+func resumeOffset(partPath string, metaPath string, expected partialDownload) int64 {
+	data, err := ioutil.ReadFile(metaPath)
+
+	if err != nil {
+		return 0
+	}
+
+	var meta partialDownload
+
+	if json.Unmarshal(data, &meta) != nil || meta != expected {
+		return 0
+	}
+
+	stat, err := os.Stat(partPath)
+
+	if err != nil || stat.Size() > expected.Size {
+		return 0
+	}
+
+	return stat.Size()
+}
+
+func writePartialDownload(metaPath string, meta partialDownload) (err error) {
+	data, err := json.Marshal(meta)
+
+	if err != nil {
+		return
+	}
+
+	return ioutil.WriteFile(metaPath, data, 0644)
+}
+
+// syncDir makes a rename durable. Errors are ignored since some platforms
+// can not sync directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+
+	if err != nil {
+		return
+	}
+
+	d.Sync()
+	d.Close()
+}
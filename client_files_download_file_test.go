@@ -0,0 +1,77 @@
+package koofrclient_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientFilesDownloadFile", func() {
+	var dir string
+	var localPath string
+	mtime := int64(1562663291000)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "koofrclient")
+		Expect(err).NotTo(HaveOccurred())
+		localPath = filepath.Join(dir, "file.txt")
+		_, err = client.FilesPutWithOptions(defaultMountId, rootPath, "file.txt", bytes.NewReader([]byte("content")), &k.PutOptions{
+			SetModified:    &mtime,
+			ForceOverwrite: true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		client.FilesDelete(defaultMountId, rootPath+"/file.txt")
+	})
+
+	It("should download a file and set its modified time", func() {
+		info, err := client.FilesDownloadFile(defaultMountId, rootPath+"/file.txt", localPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size).To(Equal(int64(7)))
+		content, err := ioutil.ReadFile(localPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal([]byte("content")))
+		stat, err := os.Stat(localPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stat.ModTime().Unix()).To(Equal(mtime / 1000))
+		_, err = os.Stat(localPath + k.PartialDownloadSuffix)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should resume a partial download", func() {
+		info, err := client.FilesInfo(defaultMountId, rootPath+"/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		meta, _ := json.Marshal(map[string]interface{}{"hash": info.Hash, "modified": info.Modified, "size": info.Size})
+		Expect(ioutil.WriteFile(localPath+k.PartialDownloadSuffix+".json", meta, 0644)).To(Succeed())
+		// a marker proves the existing bytes were kept
+		Expect(ioutil.WriteFile(localPath+k.PartialDownloadSuffix, []byte("CON"), 0644)).To(Succeed())
+
+		_, err = client.FilesDownloadFile(defaultMountId, rootPath+"/file.txt", localPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+		content, err := ioutil.ReadFile(localPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal([]byte("CONtent")))
+	})
+
+	It("should restart when the remote file changed", func() {
+		meta, _ := json.Marshal(map[string]interface{}{"hash": "other", "modified": mtime, "size": 7})
+		Expect(ioutil.WriteFile(localPath+k.PartialDownloadSuffix+".json", meta, 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(localPath+k.PartialDownloadSuffix, []byte("CON"), 0644)).To(Succeed())
+
+		_, err := client.FilesDownloadFile(defaultMountId, rootPath+"/file.txt", localPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+		content, err := ioutil.ReadFile(localPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal([]byte("content")))
+	})
+})
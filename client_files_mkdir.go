@@ -0,0 +1,64 @@
+package koofrclient
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/koofr/go-httpclient"
+)
+
+// NotDirError is returned by FilesMkdirAll when Path, a component of the
+// requested path, is a file.
+type NotDirError struct {
+	MountId string
+	Path    string
+}
+
+func (e *NotDirError) Error() string {
+	return fmt.Sprintf("Not a folder: %s:%s", e.MountId, e.Path)
+}
+
+// FilesMkdirAll creates fullPath and every missing parent folder. Folders
+// that already exist are not an error, so it can be called concurrently for
+// overlapping paths: a folder created by someone else in the meantime is
+// checked and accepted.
+func (c *KoofrClient) FilesMkdirAll(mountId string, fullPath string) (err error) {
+	p := path.Clean("/" + fullPath)
+
+	if p == "/" {
+		return
+	}
+
+	info, err := c.FilesInfo(mountId, p)
+
+	if err == nil {
+		if info.Type != "dir" {
+			return &NotDirError{mountId, p}
+		}
+		return
+	}
+
+	if !httpclient.IsInvalidStatusCode(err, http.StatusNotFound) {
+		return
+	}
+
+	if err = c.FilesMkdirAll(mountId, path.Dir(p)); err != nil {
+		return
+	}
+
+	err = c.FilesNewFolder(mountId, path.Dir(p), path.Base(p))
+
+	if !httpclient.IsInvalidStatusCode(err, http.StatusConflict) {
+		return
+	}
+
+	// created concurrently, or a file took its place
+	info, err = c.FilesInfo(mountId, p)
+
+	if err == nil && info.Type != "dir" {
+		err = &NotDirError{mountId, p}
+	}
+
+	return
+}
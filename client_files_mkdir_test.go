@@ -0,0 +1,64 @@
+package koofrclient_test
+
+import (
+	"bytes"
+	"sync"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientFilesMkdir", func() {
+	AfterEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/mkdir")
+	})
+
+	It("should create missing folders", func() {
+		Expect(client.FilesMkdirAll(defaultMountId, rootPath+"/mkdir/a/b")).To(Succeed())
+		Expect(client.FilesMkdirAll(defaultMountId, rootPath+"/mkdir/a/b")).To(Succeed())
+
+		info, err := client.FilesInfo(defaultMountId, rootPath+"/mkdir/a/b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Type).To(Equal("dir"))
+	})
+
+	It("should create overlapping folders concurrently", func() {
+		paths := []string{"/mkdir/a/b/c", "/mkdir/a/b", "/mkdir/a/d", "/mkdir/a/b/c", "/mkdir/e", "/mkdir/a"}
+		errs := make(chan error, 2*len(paths))
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 2; i++ {
+			for _, p := range paths {
+				wg.Add(1)
+				go func(p string) {
+					defer wg.Done()
+					errs <- client.FilesMkdirAll(defaultMountId, rootPath+p)
+				}(p)
+			}
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		for _, p := range paths {
+			info, err := client.FilesInfo(defaultMountId, rootPath+p)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Type).To(Equal("dir"))
+		}
+	})
+
+	It("should fail when a component is a file", func() {
+		Expect(client.FilesMkdirAll(defaultMountId, rootPath+"/mkdir")).To(Succeed())
+		_, err := client.FilesPut(defaultMountId, rootPath+"/mkdir", "file.txt", bytes.NewReader([]byte("content")))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.FilesMkdirAll(defaultMountId, rootPath+"/mkdir/file.txt/a")
+		Expect(err).To(Equal(&k.NotDirError{MountId: defaultMountId, Path: rootPath + "/mkdir/file.txt"}))
+	})
+})
@@ -0,0 +1,76 @@
+package koofrclient
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/koofr/go-httpclient"
+)
+
+// IsNotFound reports whether err is a 404 response.
+func IsNotFound(err error) bool {
+	return httpclient.IsInvalidStatusCode(err, http.StatusNotFound)
+}
+
+// CleanPath returns name as a clean absolute path.
+func CleanPath(name string) string {
+	return path.Clean("/" + name)
+}
+
+// PathError maps API errors to os errors (404 to os.ErrNotExist, 409 to
+// os.ErrExist and 403 to os.ErrPermission) and wraps them in an
+// *os.PathError, for file system adapters. It returns nil for a nil err.
+func PathError(op string, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if ise, ok := httpclient.IsInvalidStatusError(err); ok {
+		switch ise.Got {
+		case http.StatusNotFound:
+			err = os.ErrNotExist
+		case http.StatusConflict:
+			err = os.ErrExist
+		case http.StatusForbidden:
+			err = os.ErrPermission
+		}
+	}
+
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
+
+// OSFileInfo implements os.FileInfo for a file or folder. Folders have mode
+// 0755 and files 0644, since permissions are not stored.
+type OSFileInfo struct {
+	Info FileInfo
+}
+
+func (fi *OSFileInfo) Name() string {
+	return fi.Info.Name
+}
+
+func (fi *OSFileInfo) Size() int64 {
+	return fi.Info.Size
+}
+
+func (fi *OSFileInfo) Mode() os.FileMode {
+	if fi.IsDir() {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (fi *OSFileInfo) ModTime() time.Time {
+	return time.Unix(0, fi.Info.Modified*int64(time.Millisecond))
+}
+
+func (fi *OSFileInfo) IsDir() bool {
+	return fi.Info.Type == "dir"
+}
+
+// Sys returns the FileInfo.
+func (fi *OSFileInfo) Sys() interface{} {
+	return fi.Info
+}
@@ -0,0 +1,317 @@
+package koofrclient
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultParallelPartSize    = 8 * 1024 * 1024
+	DefaultParallelConcurrency = 4
+	DefaultParallelRetries     = 3
+)
+
+var ErrHashMismatch = fmt.Errorf("Downloaded content does not match file hash")
+
+type ParallelDownloadOptions struct {
+	// PartSize is the size of the ranges fetched in parallel. Defaults to
+	// DefaultParallelPartSize.
+	PartSize int64
+	// Concurrency defaults to DefaultParallelConcurrency.
+	Concurrency int
+	// Retries is the number of times a failed range is fetched again after
+	// a network or server error. Defaults to DefaultParallelRetries, negative
+	// disables retries.
+	Retries int
+	// NoVerify skips comparing the content with FileInfo.Hash.
+	NoVerify bool
+}
+
+func (o *ParallelDownloadOptions) withDefaults() ParallelDownloadOptions {
+	opts := ParallelDownloadOptions{}
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.PartSize <= 0 {
+		opts.PartSize = DefaultParallelPartSize
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultParallelConcurrency
+	}
+	if opts.Retries == 0 {
+		opts.Retries = DefaultParallelRetries
+	} else if opts.Retries < 0 {
+		opts.Retries = 0
+	}
+
+	return opts
+}
+
+func splitSpans(size int64, partSize int64) (spans []FileSpan) {
+	for start := int64(0); start < size; start += partSize {
+		end := start + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		spans = append(spans, FileSpan{Start: start, End: end})
+	}
+	return
+}
+
+// newContentHash returns a hasher for the file hash, or nil if the hash is
+// not an MD5 hex digest and can not be verified.
+func newContentHash(fileHash string) hash.Hash {
+	if len(fileHash) != hex.EncodedLen(md5.Size) {
+		return nil
+	}
+	return md5.New()
+}
+
+func verifyContentHash(h hash.Hash, fileHash string) error {
+	if !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), fileHash) {
+		return ErrHashMismatch
+	}
+	return nil
+}
+
+// fetchSpan copies a range of the file to the writer returned by writer,
+// which is called again for every retry. Like FilesPutFile, it only retries
+// errors for which isRetryable holds and waits a bit longer before every
+// attempt.
+func (c *KoofrClient) fetchSpan(mountId string, path string, span FileSpan, retries int, writer func() io.Writer) (err error) {
+	for attempt := 0; ; attempt++ {
+		var reader io.ReadCloser
+		var n int64
+
+		reader, err = c.FilesGetRange(mountId, path, &span)
+
+		if err == nil {
+			n, err = io.Copy(writer(), reader)
+			reader.Close()
+
+			if err == nil && n != span.End-span.Start+1 {
+				err = ErrDownloadSizeMismatch
+			}
+		}
+
+		if err == nil || attempt >= retries || !isRetryable(err) {
+			return
+		}
+
+		select {
+		case <-c.Context().Done():
+			return c.Context().Err()
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		}
+	}
+}
+
+// FilesDownloadParallel downloads a file into w by fetching ranges
+// concurrently. If w is also an io.ReaderAt (like *os.File), the written
+// content is read back and verified against the file hash.
+func (c *KoofrClient) FilesDownloadParallel(mountId string, path string, w io.WriterAt, options *ParallelDownloadOptions) (info FileInfo, err error) {
+	opts := options.withDefaults()
+
+	info, err = c.FilesInfo(mountId, path)
+
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.Context())
+	defer cancel()
+
+	client := c.WithContext(ctx)
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+
+	sem := make(chan struct{}, opts.Concurrency)
+
+	for _, span := range splitSpans(info.Size, opts.PartSize) {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+
+		go func(span FileSpan) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			spanErr := client.fetchSpan(mountId, path, span, opts.Retries, func() io.Writer {
+				return io.NewOffsetWriter(w, span.Start)
+			})
+
+			if spanErr != nil {
+				errOnce.Do(func() {
+					err = spanErr
+					cancel()
+				})
+			}
+		}(span)
+	}
+
+	wg.Wait()
+
+	if err != nil {
+		return
+	}
+
+	if err = c.Context().Err(); err != nil {
+		return
+	}
+
+	if opts.NoVerify {
+		return
+	}
+
+	h := newContentHash(info.Hash)
+	readerAt, ok := w.(io.ReaderAt)
+
+	if h == nil || !ok {
+		return
+	}
+
+	if _, err = io.Copy(h, io.NewSectionReader(readerAt, 0, info.Size)); err != nil {
+		return
+	}
+
+	err = verifyContentHash(h, info.Hash)
+
+	return
+}
+
+type spanResult struct {
+	data []byte
+	err  error
+}
+
+type parallelReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (r *parallelReader) Close() error {
+	r.cancel()
+	return r.PipeReader.Close()
+}
+
+// FilesGetParallel returns the file content like FilesGet, but fetches it
+// as concurrent ranges that are reassembled in order. At most Concurrency
+// parts are held in memory. A hash mismatch is returned from Read instead of
+// io.EOF.
+func (c *KoofrClient) FilesGetParallel(mountId string, path string, options *ParallelDownloadOptions) (reader io.ReadCloser, err error) {
+	opts := options.withDefaults()
+
+	info, err := c.FilesInfo(mountId, path)
+
+	if err != nil {
+		return
+	}
+
+	spans := splitSpans(info.Size, opts.PartSize)
+
+	ctx, cancel := context.WithCancel(c.Context())
+
+	client := c.WithContext(ctx)
+
+	pr, pw := io.Pipe()
+
+	results := make([]chan spanResult, len(spans))
+	for i := range results {
+		results[i] = make(chan spanResult, 1)
+	}
+
+	sem := make(chan struct{}, opts.Concurrency)
+
+	go func() {
+		for i, span := range spans {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			go func(i int, span FileSpan) {
+				w := &sliceWriter{buf: make([]byte, 0, span.End-span.Start+1)}
+
+				err := client.fetchSpan(mountId, path, span, opts.Retries, func() io.Writer {
+					w.buf = w.buf[:0]
+					return w
+				})
+
+				results[i] <- spanResult{w.buf, err}
+			}(i, span)
+		}
+	}()
+
+	go func() {
+		defer cancel()
+
+		var h hash.Hash
+		if !opts.NoVerify {
+			h = newContentHash(info.Hash)
+		}
+
+		for i := range spans {
+			var result spanResult
+
+			select {
+			case result = <-results[i]:
+			case <-ctx.Done():
+				pw.CloseWithError(ctx.Err())
+				return
+			}
+
+			<-sem
+
+			if result.err != nil {
+				pw.CloseWithError(result.err)
+				return
+			}
+
+			if h != nil {
+				h.Write(result.data)
+			}
+
+			if _, err := pw.Write(result.data); err != nil {
+				return
+			}
+		}
+
+		if h != nil {
+			pw.CloseWithError(verifyContentHash(h, info.Hash))
+			return
+		}
+
+		pw.Close()
+	}()
+
+	return &parallelReader{pr, cancel}, nil
+}
+
+type sliceWriter struct {
+	buf []byte
+}
+
+func (w *sliceWriter) Write(p []byte) (n int, err error) {
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
@@ -0,0 +1,49 @@
+package koofrclient_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientFilesParallel", func() {
+	content := make([]byte, 100*1024+17)
+	rand.Read(content)
+	options := &k.ParallelDownloadOptions{PartSize: 16 * 1024, Concurrency: 3}
+
+	BeforeEach(func() {
+		_, err := client.FilesPutWithOptions(defaultMountId, rootPath, "parallel.bin", bytes.NewReader(content), &k.PutOptions{ForceOverwrite: true})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/parallel.bin")
+	})
+
+	It("should download ranges in parallel into a file", func() {
+		f, err := ioutil.TempFile("", "koofrclient")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.Remove(f.Name())
+		defer f.Close()
+		info, err := client.FilesDownloadParallel(defaultMountId, rootPath+"/parallel.bin", f, options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size).To(Equal(int64(len(content))))
+		downloaded, err := ioutil.ReadFile(f.Name())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(downloaded).To(Equal(content))
+	})
+
+	It("should stream ranges in order", func() {
+		reader, err := client.FilesGetParallel(defaultMountId, rootPath+"/parallel.bin", options)
+		Expect(err).NotTo(HaveOccurred())
+		defer reader.Close()
+		downloaded, err := ioutil.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(downloaded).To(Equal(content))
+	})
+})
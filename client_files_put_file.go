@@ -0,0 +1,165 @@
+package koofrclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/koofr/go-httpclient"
+)
+
+type PutFileOptions struct {
+	PutOptions
+	// Name is the remote file name. Defaults to the local file name.
+	Name string
+	// ContentType defaults to the type registered for the file extension,
+	// or the type sniffed from the content if there is none.
+	ContentType string
+	// IgnoreModified disables setting the remote modified time to the local
+	// one. It has no effect if SetModified is set.
+	IgnoreModified bool
+	// Retries is the number of times a failed upload is restarted.
+	Retries int
+}
+
+// FilesPutFile uploads the local file at localPath into the folder dir.
+// Unlike FilesPut, the request has a Content-Length, the file part carries
+// the real file name and content type, and transient failures can be
+// retried since the file is read again from the start.
+func (c *KoofrClient) FilesPutFile(mountId string, dir string, localPath string, options *PutFileOptions) (fileInfo *FileInfo, err error) {
+	opts := PutFileOptions{}
+
+	if options != nil {
+		opts = *options
+	}
+
+	f, err := os.Open(localPath)
+
+	if err != nil {
+		return
+	}
+
+	defer f.Close()
+
+	stat, err := f.Stat()
+
+	if err != nil {
+		return
+	}
+
+	if !stat.Mode().IsRegular() {
+		return nil, fmt.Errorf("Not a regular file: %s", localPath)
+	}
+
+	size := stat.Size()
+
+	if opts.Name == "" {
+		opts.Name = filepath.Base(localPath)
+	}
+
+	if opts.ContentType == "" {
+		if opts.ContentType, err = detectContentType(opts.Name, io.NewSectionReader(f, 0, size)); err != nil {
+			return
+		}
+	}
+
+	putOptions := opts.PutOptions
+	putOptions.ContentLength = &size
+
+	if putOptions.SetModified == nil && !opts.IgnoreModified {
+		modified := stat.ModTime().UnixNano() / int64(time.Millisecond)
+		putOptions.SetModified = &modified
+	}
+
+	for attempt := 0; ; attempt++ {
+		reader := io.NewSectionReader(f, 0, size)
+
+		fileInfo, err = c.filesPut(mountId, dir, opts.Name, reader, &putOptions, func(request *httpclient.RequestData) error {
+			return setMultipartBody(request, "file", opts.Name, opts.ContentType, reader, size)
+		})
+
+		if err == nil || attempt >= opts.Retries || !isRetryable(err) {
+			return
+		}
+
+		select {
+		case <-c.Context().Done():
+			return nil, c.Context().Err()
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		}
+	}
+}
+
+func detectContentType(name string, reader io.Reader) (contentType string, err error) {
+	if contentType = mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return
+	}
+
+	buf := make([]byte, 512)
+
+	n, err := io.ReadFull(reader, buf)
+
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return
+	}
+
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// isRetryable reports whether an upload or download that failed with err may
+// succeed when repeated.
+func isRetryable(err error) bool {
+	if err == ErrCannotOverwrite || err == ErrInsufficientSpace {
+		return false
+	}
+
+	if ise, ok := httpclient.IsInvalidStatusError(err); ok {
+		return ise.Got >= 500
+	}
+
+	return true
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// setMultipartBody sets a multipart/form-data body with a single file part
+// of known size, so the request can be sent with a Content-Length.
+func setMultipartBody(request *httpclient.RequestData, fieldName string, fileName string, contentType string, reader io.Reader, size int64) (err error) {
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(fieldName), quoteEscaper.Replace(fileName)))
+	header.Set("Content-Type", contentType)
+
+	if _, err = writer.CreatePart(header); err != nil {
+		return
+	}
+
+	head := append([]byte{}, buf.Bytes()...)
+	buf.Reset()
+
+	if err = writer.Close(); err != nil {
+		return
+	}
+
+	tail := buf.Bytes()
+
+	if request.Headers == nil {
+		request.Headers = make(http.Header)
+	}
+
+	request.Headers.Set("Content-Type", writer.FormDataContentType())
+	request.ReqReader = io.MultiReader(bytes.NewReader(head), reader, bytes.NewReader(tail))
+	request.ReqContentLength = int64(len(head)) + size + int64(len(tail))
+
+	return
+}
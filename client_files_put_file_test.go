@@ -0,0 +1,66 @@
+package koofrclient_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientFilesPutFile", func() {
+	var dir string
+	var localPath string
+	mtime := time.Unix(1562663291, 0)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "koofrclient")
+		Expect(err).NotTo(HaveOccurred())
+		localPath = filepath.Join(dir, "file.txt")
+		err = ioutil.WriteFile(localPath, []byte("content"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.Chtimes(localPath, mtime, mtime)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		client.FilesDelete(defaultMountId, rootPath+"/file.txt")
+		client.FilesDelete(defaultMountId, rootPath+"/renamed.txt")
+	})
+
+	It("should upload a local file with its modified time", func() {
+		info, err := client.FilesPutFile(defaultMountId, rootPath, localPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Name).To(Equal("file.txt"))
+		Expect(info.Size).To(Equal(int64(7)))
+		info2, err := client.FilesInfo(defaultMountId, rootPath+"/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info2.Modified).To(Equal(int64(1562663291000)))
+		Expect(info2.ContentType).To(HavePrefix("text/plain"))
+	})
+
+	It("should upload a local file under another name", func() {
+		info, err := client.FilesPutFile(defaultMountId, rootPath, localPath, &k.PutFileOptions{
+			Name:           "renamed.txt",
+			IgnoreModified: true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Name).To(Equal("renamed.txt"))
+		Expect(info.Modified).NotTo(Equal(int64(1562663291000)))
+	})
+
+	It("should not overwrite with NoRename", func() {
+		_, err := client.FilesPutFile(defaultMountId, rootPath, localPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesPutFile(defaultMountId, rootPath, localPath, &k.PutFileOptions{
+			PutOptions: k.PutOptions{NoRename: true},
+			Retries:    3,
+		})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,157 @@
+package koofrclient
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidSeek = fmt.Errorf("Invalid seek offset")
+
+// FileReader reads a file through range requests starting at the current
+// offset. The request is only made on Read, so seeking (like
+// http.ServeContent does to find the size and serve ranges) is free.
+// Requests go to the end of the file unless an end is set with SetEnd.
+type FileReader struct {
+	client  *KoofrClient
+	mountId string
+	path    string
+	size    int64
+	offset  int64
+	end     int64
+	body    io.ReadCloser
+	bodyEnd int64
+}
+
+// NewFileReader returns a reader for a file of the given size, usually
+// FileInfo.Size.
+func (c *KoofrClient) NewFileReader(mountId string, path string, size int64) *FileReader {
+	return &FileReader{
+		client:  c,
+		mountId: mountId,
+		path:    path,
+		size:    size,
+		end:     -1,
+	}
+}
+
+// FilesOpen returns a reader for the file at path along with its info.
+func (c *KoofrClient) FilesOpen(mountId string, path string) (reader *FileReader, info FileInfo, err error) {
+	info, err = c.FilesInfo(mountId, path)
+
+	if err != nil {
+		return
+	}
+
+	if info.Type != "file" {
+		return nil, info, fmt.Errorf("Not a file: %s", path)
+	}
+
+	reader = c.NewFileReader(mountId, path, info.Size)
+
+	return
+}
+
+func (r *FileReader) Size() int64 {
+	return r.size
+}
+
+// SetEnd makes requests starting at or before end (inclusive) stop there
+// instead of at the end of the file. Reading past end still works, with a
+// new request.
+func (r *FileReader) SetEnd(end int64) {
+	r.end = end
+}
+
+// SetRangeHeader sets the end from a single range HTTP Range header, such as
+// "bytes=100-199", so serving the reader with http.ServeContent fetches only
+// the requested range. Other headers are ignored.
+func (r *FileReader) SetRangeHeader(value string) {
+	if !strings.HasPrefix(value, "bytes=") || strings.Contains(value, ",") {
+		return
+	}
+
+	i := strings.Index(value, "-")
+
+	if i <= len("bytes=") {
+		return
+	}
+
+	if end, err := strconv.ParseInt(strings.TrimSpace(value[i+1:]), 10, 64); err == nil {
+		r.SetEnd(end)
+	}
+}
+
+func (r *FileReader) Read(p []byte) (n int, err error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	if r.body == nil {
+		span := &FileSpan{Start: r.offset, End: -1}
+
+		if r.end >= r.offset && r.end < r.size-1 {
+			span.End = r.end
+		}
+
+		r.body, err = r.client.FilesGetRange(r.mountId, r.path, span)
+
+		if err != nil {
+			return
+		}
+
+		r.bodyEnd = span.End
+	}
+
+	n, err = r.body.Read(p)
+	r.offset += int64(n)
+
+	if err == io.EOF && r.bodyEnd != -1 && r.offset == r.bodyEnd+1 {
+		// the request stopped at the end, continue with a new one
+		r.Close()
+		err = nil
+
+		if n == 0 {
+			return r.Read(p)
+		}
+	}
+
+	return
+}
+
+func (r *FileReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, ErrInvalidSeek
+	}
+
+	if offset < 0 {
+		return 0, ErrInvalidSeek
+	}
+
+	if offset != r.offset {
+		r.Close()
+		r.offset = offset
+	}
+
+	return offset, nil
+}
+
+// Close closes the current response body. The reader can still be used
+// after Close; the next Read starts a new request.
+func (r *FileReader) Close() (err error) {
+	if r.body == nil {
+		return
+	}
+
+	err = r.body.Close()
+	r.body = nil
+
+	return
+}
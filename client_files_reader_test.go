@@ -0,0 +1,44 @@
+package koofrclient_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientFilesReader", func() {
+	BeforeEach(func() {
+		_, err := client.FilesPutWithOptions(defaultMountId, rootPath, "reader.txt", bytes.NewReader([]byte("content")), &k.PutOptions{ForceOverwrite: true})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/reader.txt")
+	})
+
+	It("should read a file from any offset", func() {
+		reader, info, err := client.FilesOpen(defaultMountId, rootPath+"/reader.txt")
+		Expect(err).NotTo(HaveOccurred())
+		defer reader.Close()
+		Expect(info.Size).To(Equal(int64(7)))
+
+		size, err := reader.Seek(0, io.SeekEnd)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(size).To(Equal(int64(7)))
+
+		_, err = reader.Seek(3, io.SeekStart)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := ioutil.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal([]byte("tent")))
+	})
+
+	It("should not open folders", func() {
+		_, _, err := client.FilesOpen(defaultMountId, rootPath)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,193 @@
+package koofrclient
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+var ErrRemoveRoot = fmt.Errorf("Refusing to remove mount root")
+
+type RemoveAllOptions struct {
+	// Include limits the removal to files matching one of the patterns.
+	// Patterns use path.Match syntax and are matched against the path
+	// relative to the removed folder, or against the name if the pattern
+	// has no slash.
+	Include []string
+	// Exclude keeps files and whole folders matching one of the patterns.
+	Exclude []string
+	// ModifiedBefore only removes files modified before this time (in
+	// milliseconds, like FileInfo.Modified).
+	ModifiedBefore *int64
+	// LargerThan only removes files larger than this many bytes.
+	LargerThan *int64
+}
+
+// RemoveAllResult lists the removed files and folders, and the files that
+// were kept because they changed after they were listed. Paths are
+// relative to the mount root.
+type RemoveAllResult struct {
+	Removed []FileInfo
+	Changed []FileInfo
+}
+
+// FilesRemoveAll removes the file or folder at p and everything in it that
+// matches the options. Every file is deleted only if its hash still matches
+// the listing. Folders are removed once everything in them is removed,
+// but folders that were empty to begin with are left alone when filters are
+// set. Mount roots are never removed.
+func (c *KoofrClient) FilesRemoveAll(mountId string, p string, options *RemoveAllOptions) (result *RemoveAllResult, err error) {
+	opts := RemoveAllOptions{}
+
+	if options != nil {
+		opts = *options
+	}
+
+	p = path.Clean("/" + p)
+
+	if p == "/" {
+		return nil, ErrRemoveRoot
+	}
+
+	for _, patterns := range [][]string{opts.Include, opts.Exclude} {
+		for _, pattern := range patterns {
+			if _, err = path.Match(pattern, ""); err != nil {
+				return
+			}
+		}
+	}
+
+	tree, err := c.FilesTree(mountId, p)
+
+	if err != nil {
+		return
+	}
+
+	r := &removeAll{
+		client:  c,
+		mountId: mountId,
+		opts:    opts,
+		result:  &RemoveAllResult{},
+	}
+
+	_, err = r.remove(&tree, p, "")
+
+	return r.result, err
+}
+
+type removeAll struct {
+	client  *KoofrClient
+	mountId string
+	opts    RemoveAllOptions
+	result  *RemoveAllResult
+}
+
+func (r *removeAll) filtered() bool {
+	o := r.opts
+	return len(o.Include) > 0 || len(o.Exclude) > 0 || o.ModifiedBefore != nil || o.LargerThan != nil
+}
+
+// remove removes tree at p, rel being its path relative to the removed
+// folder. removed reports whether p is gone.
+func (r *removeAll) remove(tree *FileTree, p string, rel string) (removed bool, err error) {
+	info := tree.FileInfo
+	info.Path = p
+
+	if rel != "" && matchAny(r.opts.Exclude, rel) {
+		return
+	}
+
+	if tree.Type != "dir" {
+		if !r.selected(info, rel) {
+			return
+		}
+
+		err = r.client.FilesDeleteWithOptions(r.mountId, p, &DeleteOptions{RemoveIfHash: &info.Hash})
+
+		if err == ErrCannotRemove {
+			r.result.Changed = append(r.result.Changed, info)
+			return false, nil
+		}
+
+		if err != nil {
+			return
+		}
+
+		r.result.Removed = append(r.result.Removed, info)
+
+		return true, nil
+	}
+
+	all := true
+	some := false
+
+	for _, child := range tree.Children {
+		childRemoved, err := r.remove(child, path.Join(p, child.Name), path.Join(rel, child.Name))
+
+		if err != nil {
+			return false, err
+		}
+
+		all = all && childRemoved
+		some = some || childRemoved
+	}
+
+	if !all || (!some && r.filtered()) {
+		return
+	}
+
+	err = r.client.FilesDeleteWithOptions(r.mountId, p, &DeleteOptions{RemoveIfEmpty: true})
+
+	if err == ErrCannotRemove {
+		// something was added in the meantime
+		return false, nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	r.result.Removed = append(r.result.Removed, info)
+
+	return true, nil
+}
+
+func (r *removeAll) selected(info FileInfo, rel string) bool {
+	if rel == "" {
+		rel = info.Name
+	}
+
+	if matchAny(r.opts.Exclude, rel) {
+		return false
+	}
+
+	if len(r.opts.Include) > 0 && !matchAny(r.opts.Include, rel) {
+		return false
+	}
+
+	if r.opts.ModifiedBefore != nil && info.Modified >= *r.opts.ModifiedBefore {
+		return false
+	}
+
+	if r.opts.LargerThan != nil && info.Size <= *r.opts.LargerThan {
+		return false
+	}
+
+	return true
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		name := rel
+
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
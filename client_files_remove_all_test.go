@@ -0,0 +1,65 @@
+package koofrclient_test
+
+import (
+	"bytes"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientFilesRemoveAll", func() {
+	base := func() string {
+		return rootPath + "/removeall"
+	}
+
+	put := func(dir string, name string, content string, modified int64) {
+		Expect(client.FilesMkdirAll(defaultMountId, base()+dir)).To(Succeed())
+		_, err := client.FilesPutWithOptions(defaultMountId, base()+dir, name, bytes.NewReader([]byte(content)), &k.PutOptions{SetModified: &modified})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		put("", "old.log", "old content", 1000)
+		put("", "new.log", "new content", 9000)
+		put("/keep", "old.log", "old content", 1000)
+		put("/sub", "small.log", "s", 1000)
+	})
+
+	AfterEach(func() {
+		client.FilesDelete(defaultMountId, base())
+	})
+
+	It("should refuse to remove the mount root", func() {
+		_, err := client.FilesRemoveAll(defaultMountId, "/", nil)
+		Expect(err).To(Equal(k.ErrRemoveRoot))
+	})
+
+	It("should only remove matching files", func() {
+		before, larger := int64(5000), int64(1)
+
+		result, err := client.FilesRemoveAll(defaultMountId, base(), &k.RemoveAllOptions{
+			Include:        []string{"*.log"},
+			Exclude:        []string{"keep"},
+			ModifiedBefore: &before,
+			LargerThan:     &larger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Removed).To(HaveLen(1))
+		Expect(result.Removed[0].Path).To(Equal(base() + "/old.log"))
+
+		_, err = client.FilesInfo(defaultMountId, base()+"/keep/old.log")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesInfo(defaultMountId, base()+"/sub/small.log")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should remove everything without filters", func() {
+		result, err := client.FilesRemoveAll(defaultMountId, base(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Removed).To(HaveLen(7))
+
+		_, err = client.FilesInfo(defaultMountId, base())
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -120,4 +120,50 @@ var _ = Describe("ClientFiles", func() {
 		err = client.FilesDelete(defaultMountId, rootPath+"/file.txt")
 		Expect(err).NotTo(HaveOccurred())
 	})
+
+	It("should get a file only if it was modified", func() {
+		mtime := int64(1562663291000)
+		putOptions := koofrclient.PutOptions{
+			SetModified: &mtime,
+		}
+		_, err := client.FilesPutWithOptions(defaultMountId, rootPath, "file.txt", bytes.NewReader([]byte("content")), &putOptions)
+		Expect(err).NotTo(HaveOccurred())
+		info, err := client.FilesInfo(defaultMountId, rootPath+"/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		content, err := client.FilesGetWithOptions(defaultMountId, rootPath+"/file.txt", &koofrclient.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		data, err := ioutil.ReadAll(content)
+		Expect(err).NotTo(HaveOccurred())
+		content.Close()
+		Expect(data).To(Equal([]byte("content")))
+		Expect(content.ContentLength).To(Equal(int64(7)))
+		_, err = client.FilesGetWithOptions(defaultMountId, rootPath+"/file.txt", &koofrclient.GetOptions{IfNoneMatch: &info.Hash})
+		Expect(err).To(Equal(koofrclient.ErrNotModified))
+		_, err = client.FilesGetWithOptions(defaultMountId, rootPath+"/file.txt", &koofrclient.GetOptions{IfModifiedSince: &mtime})
+		Expect(err).To(Equal(koofrclient.ErrNotModified))
+		err = client.FilesDelete(defaultMountId, rootPath+"/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should fail before uploading when there is not enough space", func() {
+		mount, err := client.MountsDetails(defaultMountId)
+		Expect(err).NotTo(HaveOccurred())
+		size := mount.SpaceFree() + 1
+		putOptions := koofrclient.PutOptions{
+			ContentLength: &size,
+			CheckSpace:    true,
+		}
+		_, err = client.FilesPutWithOptions(defaultMountId, rootPath, "file.txt", bytes.NewReader([]byte("content")), &putOptions)
+		Expect(err).To(Equal(koofrclient.ErrInsufficientSpace))
+		_, err = client.FilesInfo(defaultMountId, rootPath+"/file.txt")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should upload when there is enough space", func() {
+		putOptions := koofrclient.PutOptions{
+			CheckSpace: true,
+		}
+		_, err := client.FilesPutWithOptions(defaultMountId, rootPath, "file.txt", bytes.NewReader([]byte("content")), &putOptions)
+		Expect(err).NotTo(HaveOccurred())
+	})
 })
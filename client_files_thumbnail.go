@@ -0,0 +1,201 @@
+package koofrclient
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/koofr/go-httpclient"
+)
+
+var ErrThumbnailUnsupported = fmt.Errorf("Thumbnail not available for this file type")
+var ErrThumbnailTooLarge = fmt.Errorf("Image is too large for a thumbnail")
+
+// MaxThumbnailPixels is the largest image, in pixels, Thumbnail decodes.
+// Decoded images take 4 to 8 bytes per pixel, so the limit keeps a small
+// file declaring huge dimensions from exhausting memory.
+const MaxThumbnailPixels = 50 * 1000 * 1000
+
+func (c *KoofrClient) filesContent(mountId string, endpoint string, params url.Values) (content *FileContent, err error) {
+	request := httpclient.RequestData{
+		Method:         "GET",
+		Path:           "/content/api/v2/mounts/" + mountId + "/files/" + endpoint,
+		Params:         params,
+		ExpectedStatus: []int{http.StatusOK},
+	}
+
+	res, err := c.Request(&request)
+
+	if err != nil {
+		return
+	}
+
+	content = newFileContent(res)
+
+	return
+}
+
+// FilesThumbnail returns an image thumbnail that fits into a size x size
+// box. If the server has no thumbnail for the file, one is generated on the
+// client for JPEG, PNG and GIF images; other files fail with
+// ErrThumbnailUnsupported.
+func (c *KoofrClient) FilesThumbnail(mountId string, path string, size int) (content *FileContent, err error) {
+	params := url.Values{}
+	params.Set("path", path)
+	params.Set("size", strconv.Itoa(size))
+
+	content, err = c.filesContent(mountId, "thumbnail", params)
+
+	if err != nil && (httpclient.IsInvalidStatusCode(err, http.StatusNotFound) ||
+		httpclient.IsInvalidStatusCode(err, http.StatusNotImplemented)) {
+		return c.filesThumbnailLocal(mountId, path, size)
+	}
+
+	return
+}
+
+// FilesPreview returns a server rendered preview of a document (for example
+// a PDF or image rendition of an office file).
+func (c *KoofrClient) FilesPreview(mountId string, path string) (content *FileContent, err error) {
+	params := url.Values{}
+	params.Set("path", path)
+
+	return c.filesContent(mountId, "preview", params)
+}
+
+func (c *KoofrClient) filesThumbnailLocal(mountId string, path string, size int) (content *FileContent, err error) {
+	info, err := c.FilesInfo(mountId, path)
+
+	if err != nil {
+		return
+	}
+
+	switch info.ContentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, ErrThumbnailUnsupported
+	}
+
+	reader, err := c.FilesGet(mountId, path)
+
+	if err != nil {
+		return
+	}
+
+	defer reader.Close()
+
+	buf := &bytes.Buffer{}
+
+	contentType, err := Thumbnail(buf, reader, size)
+
+	if err != nil {
+		return
+	}
+
+	content = &FileContent{
+		ReadCloser:    ioutil.NopCloser(buf),
+		ContentType:   contentType,
+		ContentLength: int64(buf.Len()),
+	}
+
+	return
+}
+
+// Thumbnail decodes a JPEG, PNG or GIF image from r, scales it to fit into a
+// size x size box (never enlarging it) and writes it to w. JPEG images stay
+// JPEG, everything else is written as PNG to keep transparency. Images
+// larger than MaxThumbnailPixels fail with ErrThumbnailTooLarge before they
+// are decoded.
+func Thumbnail(w io.Writer, r io.Reader, size int) (contentType string, err error) {
+	header := &bytes.Buffer{}
+
+	config, _, err := image.DecodeConfig(io.TeeReader(r, header))
+
+	if err != nil {
+		return "", ErrThumbnailUnsupported
+	}
+
+	if int64(config.Width)*int64(config.Height) > MaxThumbnailPixels {
+		return "", ErrThumbnailTooLarge
+	}
+
+	src, format, err := image.Decode(io.MultiReader(header, r))
+
+	if err != nil {
+		return "", ErrThumbnailUnsupported
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, height*size/width
+		} else {
+			width, height = width*size/height, size
+		}
+		if width < 1 {
+			width = 1
+		}
+		if height < 1 {
+			height = 1
+		}
+	}
+
+	dst := scaleImage(src, width, height)
+
+	if format == "jpeg" {
+		return "image/jpeg", jpeg.Encode(w, dst, &jpeg.Options{Quality: 85})
+	}
+
+	return "image/png", png.Encode(w, dst)
+}
+
+// scaleImage downscales src to width x height by averaging the source pixels
+// covered by every destination pixel.
+func scaleImage(src image.Image, width int, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,66 @@
+package koofrclient_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientFilesThumbnail", func() {
+	encodePNG := func(width int, height int) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		img.Set(0, 0, color.White)
+		buf := &bytes.Buffer{}
+		Expect(png.Encode(buf, img)).To(Succeed())
+		return buf.Bytes()
+	}
+
+	It("should scale images to fit the size", func() {
+		buf := &bytes.Buffer{}
+		contentType, err := k.Thumbnail(buf, bytes.NewReader(encodePNG(400, 200)), 100)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contentType).To(Equal("image/png"))
+		config, err := png.DecodeConfig(buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Width).To(Equal(100))
+		Expect(config.Height).To(Equal(50))
+	})
+
+	It("should not enlarge small images", func() {
+		buf := &bytes.Buffer{}
+		_, err := k.Thumbnail(buf, bytes.NewReader(encodePNG(20, 10)), 100)
+		Expect(err).NotTo(HaveOccurred())
+		config, err := png.DecodeConfig(buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Width).To(Equal(20))
+	})
+
+	It("should reject files that are not images", func() {
+		_, err := k.Thumbnail(&bytes.Buffer{}, bytes.NewReader([]byte("content")), 100)
+		Expect(err).To(Equal(k.ErrThumbnailUnsupported))
+	})
+
+	It("should reject images with too many pixels before decoding them", func() {
+		// a GIF header declaring a 65535 x 65535 image
+		header := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")
+		_, err := k.Thumbnail(&bytes.Buffer{}, bytes.NewReader(header), 100)
+		Expect(err).To(Equal(k.ErrThumbnailTooLarge))
+	})
+
+	It("should get a thumbnail of an uploaded image", func() {
+		_, err := client.FilesPut(defaultMountId, rootPath, "image.png", bytes.NewReader(encodePNG(400, 200)))
+		Expect(err).NotTo(HaveOccurred())
+		defer client.FilesDelete(defaultMountId, rootPath+"/image.png")
+		content, err := client.FilesThumbnail(defaultMountId, rootPath+"/image.png", 100)
+		Expect(err).NotTo(HaveOccurred())
+		defer content.Close()
+		Expect(content.ContentType).To(HavePrefix("image/"))
+		_, _, err = image.DecodeConfig(content)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,182 @@
+package koofrclient
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/koofr/go-httpclient"
+)
+
+// FilesDownloadZip returns a zip archive of the given files and folders
+// built by the server. Entries are named relative to the parent of each
+// path. If the server does not support zip downloads (405 or 501), or if
+// several paths have the same name, the archive is built on the client with
+// FilesZip instead.
+func (c *KoofrClient) FilesDownloadZip(mountId string, paths []string) (reader io.ReadCloser, err error) {
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = path.Base(path.Clean(p))
+	}
+
+	if !sameStrings(names, uniqueNames(names)) {
+		return c.FilesZip(mountId, paths)
+	}
+
+	params := url.Values{}
+	for _, p := range paths {
+		params.Add("path", p)
+	}
+
+	request := httpclient.RequestData{
+		Method:         "GET",
+		Path:           "/content/api/v2/mounts/" + mountId + "/files/zip",
+		Params:         params,
+		ExpectedStatus: []int{http.StatusOK},
+	}
+
+	res, err := c.Request(&request)
+
+	if err != nil {
+		// a 404 is a missing path, not a missing endpoint
+		if httpclient.IsInvalidStatusCode(err, http.StatusMethodNotAllowed) ||
+			httpclient.IsInvalidStatusCode(err, http.StatusNotImplemented) {
+			return c.FilesZip(mountId, paths)
+		}
+		return
+	}
+
+	reader = res.Body
+
+	return
+}
+
+// FilesZip streams a zip archive of the given files and folders built on
+// the client from FilesTree and FilesGet. Nothing is buffered on disk; errors
+// while building the archive are returned from Read. Paths with the same name
+// are renamed to "name (n).ext".
+func (c *KoofrClient) FilesZip(mountId string, paths []string) (reader io.ReadCloser, err error) {
+	trees := make([]*FileTree, len(paths))
+	names := make([]string, len(paths))
+
+	for i, p := range paths {
+		tree, err := c.FilesTree(mountId, p)
+
+		if err != nil {
+			return nil, err
+		}
+
+		trees[i] = &tree
+		names[i] = tree.Name
+	}
+
+	names = uniqueNames(names)
+
+	r, w := io.Pipe()
+
+	go func() {
+		zw := zip.NewWriter(w)
+
+		for i, tree := range trees {
+			if err := c.zipTree(zw, mountId, path.Clean(paths[i]), names[i], tree); err != nil {
+				w.CloseWithError(err)
+				return
+			}
+		}
+
+		w.CloseWithError(zw.Close())
+	}()
+
+	return r, nil
+}
+
+func (c *KoofrClient) zipTree(zw *zip.Writer, mountId string, p string, name string, tree *FileTree) (err error) {
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Unix(0, tree.Modified*int64(time.Millisecond)),
+	}
+
+	if tree.Type == "dir" {
+		header.Name += "/"
+		header.Method = zip.Store
+
+		// the mount root has no name, its children go to the archive root
+		if name != "" {
+			if _, err = zw.CreateHeader(header); err != nil {
+				return
+			}
+		}
+
+		for _, child := range tree.Children {
+			if err = c.zipTree(zw, mountId, path.Join(p, child.Name), path.Join(name, child.Name), child); err != nil {
+				return
+			}
+		}
+
+		return
+	}
+
+	w, err := zw.CreateHeader(header)
+
+	if err != nil {
+		return
+	}
+
+	reader, err := c.FilesGet(mountId, p)
+
+	if err != nil {
+		return
+	}
+
+	defer reader.Close()
+
+	_, err = io.Copy(w, reader)
+
+	return
+}
+
+// uniqueNames renames repeated names to "name (n).ext", the same way the
+// server renames uploads. The empty name of a mount root is left alone.
+func uniqueNames(names []string) []string {
+	used := map[string]bool{}
+	unique := make([]string, len(names))
+
+	for i, name := range names {
+		unique[i] = name
+
+		if name == "" {
+			continue
+		}
+
+		ext := path.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+
+		for n := 1; used[unique[i]]; n++ {
+			unique[i] = fmt.Sprintf("%s (%d)%s", base, n, ext)
+		}
+
+		used[unique[i]] = true
+	}
+
+	return unique
+}
+
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
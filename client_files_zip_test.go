@@ -0,0 +1,83 @@
+package koofrclient_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientFilesZip", func() {
+	BeforeEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/zip")
+		err := client.FilesNewFolder(defaultMountId, rootPath, "zip")
+		Expect(err).NotTo(HaveOccurred())
+		err = client.FilesNewFolder(defaultMountId, rootPath+"/zip", "dir")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesPut(defaultMountId, rootPath+"/zip/dir", "a.txt", bytes.NewReader([]byte("a")))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesPut(defaultMountId, rootPath+"/zip", "b.txt", bytes.NewReader([]byte("b")))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/zip")
+	})
+
+	readZip := func(data []byte) map[string]string {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		Expect(err).NotTo(HaveOccurred())
+		files := map[string]string{}
+		for _, f := range zr.File {
+			r, err := f.Open()
+			Expect(err).NotTo(HaveOccurred())
+			content, err := ioutil.ReadAll(r)
+			Expect(err).NotTo(HaveOccurred())
+			files[f.Name] = string(content)
+		}
+		return files
+	}
+
+	It("should download a zip of a folder and a file", func() {
+		reader, err := client.FilesDownloadZip(defaultMountId, []string{rootPath + "/zip/dir", rootPath + "/zip/b.txt"})
+		Expect(err).NotTo(HaveOccurred())
+		data, err := ioutil.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		files := readZip(data)
+		Expect(files).To(HaveKeyWithValue("dir/a.txt", "a"))
+		Expect(files).To(HaveKeyWithValue("b.txt", "b"))
+	})
+
+	It("should build a zip on the client", func() {
+		reader, err := client.FilesZip(defaultMountId, []string{rootPath + "/zip/dir", rootPath + "/zip/b.txt"})
+		Expect(err).NotTo(HaveOccurred())
+		data, err := ioutil.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readZip(data)).To(Equal(map[string]string{
+			"dir/":      "",
+			"dir/a.txt": "a",
+			"b.txt":     "b",
+		}))
+	})
+	It("should rename entries with the same name", func() {
+		err := client.FilesNewFolder(defaultMountId, rootPath+"/zip", "other")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesPut(defaultMountId, rootPath+"/zip/other", "b.txt", bytes.NewReader([]byte("other")))
+		Expect(err).NotTo(HaveOccurred())
+		reader, err := client.FilesDownloadZip(defaultMountId, []string{rootPath + "/zip/b.txt", rootPath + "/zip/other/b.txt"})
+		Expect(err).NotTo(HaveOccurred())
+		data, err := ioutil.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readZip(data)).To(Equal(map[string]string{
+			"b.txt":     "b",
+			"b (1).txt": "other",
+		}))
+	})
+
+	It("should fail for missing paths", func() {
+		_, err := client.FilesDownloadZip(defaultMountId, []string{rootPath + "/zip/missing.txt"})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,224 @@
+package koofrclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/koofr/go-httpclient"
+)
+
+const Redacted = "REDACTED"
+
+// RequestEvent describes a finished API call. Secrets (the Authorization
+// header and any "password" or "token" field or parameter, like
+// TokenRequest.Password, Link.Password or the Token returned by
+// Authenticate) are already redacted.
+type RequestEvent struct {
+	Method  string
+	Path    string
+	Params  url.Values
+	MountId string
+	Headers http.Header
+	// Status is 0 if no response was received.
+	Status   int
+	Duration time.Duration
+	// BytesSent is the size of the request body.
+	BytesSent int64
+	// BytesReceived is the response Content-Length, -1 if unknown. Streamed
+	// download bodies are still unread when the event is emitted.
+	BytesReceived int64
+	RequestId     string
+	Err           error
+	// requestValue and responseValue are the JSON encoded bodies, encoded
+	// only when asked for.
+	requestValue  interface{}
+	responseValue interface{}
+}
+
+// RequestBody returns the redacted JSON request body, or nil if the body was
+// not JSON. It is encoded on every call, so hooks that do not need it cost
+// nothing extra.
+func (e *RequestEvent) RequestBody() json.RawMessage {
+	if e.requestValue == nil {
+		return nil
+	}
+
+	return redactJSON(e.requestValue)
+}
+
+// ResponseBody returns the redacted JSON response body, or nil if there was
+// none. The decoded value belongs to the caller of the API, so it should be
+// read while the hook runs.
+func (e *RequestEvent) ResponseBody() json.RawMessage {
+	if e.responseValue == nil {
+		return nil
+	}
+
+	return redactJSON(e.responseValue)
+}
+
+type RequestHook func(event *RequestEvent)
+
+// AddRequestHook registers a hook that is called after every API call made
+// by c and by copies of c created afterwards. Copies created before keep
+// their own hooks.
+func (c *KoofrClient) AddRequestHook(hook RequestHook) {
+	// copies share the slice, so never append in place
+	c.hooks = append(c.hooks[:len(c.hooks):len(c.hooks)], hook)
+}
+
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	r.n += int64(n)
+	return
+}
+
+func (c *KoofrClient) hookedRequest(request *httpclient.RequestData) (res *http.Response, err error) {
+	var counter *countingReader
+
+	if request.ReqReader != nil {
+		counter = &countingReader{Reader: request.ReqReader}
+		request.ReqReader = counter
+	}
+
+	start := time.Now()
+
+	res, err = c.HTTPClient.Request(request)
+
+	event := &RequestEvent{
+		Method:        request.Method,
+		Path:          request.Path,
+		Params:        redactParams(request.Params),
+		MountId:       mountIdFromPath(request.Path),
+		Headers:       redactHeaders(c.Headers, request.Headers),
+		Duration:      time.Since(start),
+		BytesSent:     request.ReqContentLength,
+		BytesReceived: -1,
+		Err:           err,
+	}
+
+	if counter != nil {
+		event.BytesSent = counter.n
+	}
+
+	if res != nil {
+		event.Status = res.StatusCode
+		event.BytesReceived = res.ContentLength
+		event.RequestId = res.Header.Get("X-Request-Id")
+	}
+
+	if request.ReqEncoding == httpclient.EncodingJSON && request.ReqValue != nil {
+		event.requestValue = request.ReqValue
+	}
+
+	if err == nil && request.RespEncoding == httpclient.EncodingJSON && request.RespValue != nil {
+		event.responseValue = request.RespValue
+	}
+
+	for _, hook := range c.hooks {
+		hook(event)
+	}
+
+	return
+}
+
+func mountIdFromPath(p string) string {
+	const prefix = "/api/v2/mounts/"
+
+	p = strings.TrimPrefix(p, "/content")
+
+	if !strings.HasPrefix(p, prefix) {
+		return ""
+	}
+
+	p = strings.TrimPrefix(p, prefix)
+
+	if i := strings.Index(p, "/"); i >= 0 {
+		p = p[:i]
+	}
+
+	return p
+}
+
+func isSecret(key string) bool {
+	return strings.EqualFold(key, "password") || strings.EqualFold(key, "token")
+}
+
+func redactParams(params url.Values) url.Values {
+	redacted := url.Values{}
+
+	for key, values := range params {
+		if isSecret(key) {
+			values = []string{Redacted}
+		}
+		redacted[key] = values
+	}
+
+	return redacted
+}
+
+func redactHeaders(headers ...http.Header) http.Header {
+	redacted := http.Header{}
+
+	for _, h := range headers {
+		for key, values := range h {
+			if key == "Authorization" || isSecret(key) {
+				values = []string{Redacted}
+			}
+			redacted[key] = values
+		}
+	}
+
+	return redacted
+}
+
+// redactJSON encodes v as JSON with all secret fields replaced.
+func redactJSON(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		return nil
+	}
+
+	var generic interface{}
+
+	if err = json.Unmarshal(data, &generic); err != nil {
+		return nil
+	}
+
+	data, err = json.Marshal(redactValue(generic))
+
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSecret(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redactValue(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,58 @@
+package koofrclient
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogRequestHook returns a RequestHook that logs every API call to
+// logger, at debug level for successful calls and at warn level for failed
+// ones. Request and response bodies are logged only at trace level
+// (slog.LevelDebug-4).
+func NewSlogRequestHook(logger *slog.Logger) RequestHook {
+	return func(event *RequestEvent) {
+		ctx := context.Background()
+
+		level := slog.LevelDebug
+		if event.Err != nil {
+			level = slog.LevelWarn
+		}
+
+		if !logger.Enabled(ctx, level) {
+			return
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", event.Method),
+			slog.String("path", event.Path),
+			slog.Int("status", event.Status),
+			slog.Duration("duration", event.Duration),
+			slog.Int64("bytesSent", event.BytesSent),
+			slog.Int64("bytesReceived", event.BytesReceived),
+		}
+
+		if event.MountId != "" {
+			attrs = append(attrs, slog.String("mountId", event.MountId))
+		}
+		if p := event.Params.Get("path"); p != "" {
+			attrs = append(attrs, slog.String("filePath", p))
+		}
+		if event.RequestId != "" {
+			attrs = append(attrs, slog.String("requestId", event.RequestId))
+		}
+		if event.Err != nil {
+			attrs = append(attrs, slog.String("error", event.Err.Error()))
+		}
+
+		if logger.Enabled(ctx, slog.LevelDebug-4) {
+			if body := event.RequestBody(); body != nil {
+				attrs = append(attrs, slog.String("requestBody", string(body)))
+			}
+			if body := event.ResponseBody(); body != nil {
+				attrs = append(attrs, slog.String("responseBody", string(body)))
+			}
+		}
+
+		logger.LogAttrs(ctx, level, "koofr api request", attrs...)
+	}
+}
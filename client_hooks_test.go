@@ -0,0 +1,94 @@
+package koofrclient_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientHooks", func() {
+	It("should call hooks with redacted request details", func() {
+		c := k.NewKoofrClient(apiBase, true)
+		events := []*k.RequestEvent{}
+		c.AddRequestHook(func(event *k.RequestEvent) {
+			events = append(events, event)
+		})
+
+		err := c.Authenticate(email, password)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].Method).To(Equal("POST"))
+		Expect(events[0].Path).To(Equal("/token"))
+		Expect(events[0].Status).To(Equal(200))
+		Expect(events[0].BytesSent).To(BeNumerically(">", 0))
+		Expect(string(events[0].RequestBody())).NotTo(ContainSubstring(password))
+		Expect(string(events[0].RequestBody())).To(ContainSubstring(k.Redacted))
+		Expect(string(events[0].ResponseBody())).NotTo(ContainSubstring(c.GetToken()))
+
+		_, err = c.FilesInfo(defaultMountId, rootPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events).To(HaveLen(2))
+		Expect(events[1].MountId).To(Equal(defaultMountId))
+		Expect(events[1].Params.Get("path")).To(Equal(rootPath))
+		Expect(events[1].Headers.Get("Authorization")).To(Equal(k.Redacted))
+		Expect(events[1].Duration).To(BeNumerically(">", 0))
+	})
+
+	It("should report failed requests", func() {
+		c := k.NewKoofrClient(apiBase, true)
+		c.SetToken(client.GetToken())
+		var event *k.RequestEvent
+		c.AddRequestHook(func(e *k.RequestEvent) {
+			event = e
+		})
+		_, err := c.FilesInfo(defaultMountId, rootPath+"/does-not-exist")
+		Expect(err).To(HaveOccurred())
+		Expect(event.Status).To(Equal(404))
+		Expect(event.Err).To(Equal(err))
+	})
+
+	It("should not add hooks to earlier copies", func() {
+		c := k.NewKoofrClient(apiBase, true)
+		c.SetToken(client.GetToken())
+		c.AddRequestHook(func(e *k.RequestEvent) {})
+		c.AddRequestHook(func(e *k.RequestEvent) {})
+		c.AddRequestHook(func(e *k.RequestEvent) {})
+		a := c.WithContext(context.Background())
+		b := c.WithContext(context.Background())
+		calls := []string{}
+		a.AddRequestHook(func(e *k.RequestEvent) {
+			calls = append(calls, "a")
+		})
+		b.AddRequestHook(func(e *k.RequestEvent) {
+			calls = append(calls, "b")
+		})
+		_, err := a.FilesInfo(defaultMountId, rootPath)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = c.FilesInfo(defaultMountId, rootPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(calls).To(Equal([]string{"a"}))
+	})
+
+	It("should log requests with slog", func() {
+		buf := &bytes.Buffer{}
+		logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug - 4}))
+		c := k.NewKoofrClient(apiBase, true)
+		c.AddRequestHook(k.NewSlogRequestHook(logger))
+		err := c.Authenticate(email, password)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buf.String()).NotTo(ContainSubstring(password))
+		Expect(c.GetToken()).NotTo(BeEmpty())
+		Expect(buf.String()).NotTo(ContainSubstring(c.GetToken()))
+		var record map[string]interface{}
+		err = json.Unmarshal([]byte(strings.TrimSpace(buf.String())), &record)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(record["path"]).To(Equal("/token"))
+		Expect(record["status"]).To(BeNumerically("==", 200))
+	})
+})
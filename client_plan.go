@@ -0,0 +1,198 @@
+package koofrclient
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	PlanDelete    = "delete"
+	PlanMove      = "move"
+	PlanCopy      = "copy"
+	PlanPut       = "put"
+	PlanNewFolder = "mkdir"
+)
+
+// FileOperations are the modifying file operations shared by KoofrClient
+// and Plan, so code can be run for real or only recorded.
+type FileOperations interface {
+	FilesDelete(mountId string, path string) error
+	FilesDeleteWithOptions(mountId string, path string, deleteOptions *DeleteOptions) error
+	FilesNewFolder(mountId string, path string, name string) error
+	FilesCopy(mountId string, path string, toMountId string, toPath string, options CopyOptions) error
+	FilesMove(mountId string, path string, toMountId string, toPath string) error
+	FilesPut(mountId string, path string, name string, reader io.Reader) (string, error)
+	FilesPutWithOptions(mountId string, path string, name string, reader io.Reader, putOptions *PutOptions) (*FileInfo, error)
+}
+
+var _ FileOperations = (*KoofrClient)(nil)
+var _ FileOperations = (*Plan)(nil)
+
+// PlanOp is a recorded operation. Only the fields used by Op are set.
+type PlanOp struct {
+	Op            string         `json:"op"`
+	MountId       string         `json:"mountId"`
+	Path          string         `json:"path"`
+	ToMountId     string         `json:"toMountId,omitempty"`
+	ToPath        string         `json:"toPath,omitempty"`
+	Name          string         `json:"name,omitempty"`
+	Content       []byte         `json:"content,omitempty"`
+	CopyOptions   *CopyOptions   `json:"copyOptions,omitempty"`
+	PutOptions    *PutOptions    `json:"putOptions,omitempty"`
+	DeleteOptions *DeleteOptions `json:"deleteOptions,omitempty"`
+}
+
+func (op PlanOp) String() string {
+	from := op.MountId + ":" + op.Path
+
+	switch op.Op {
+	case PlanMove, PlanCopy:
+		return fmt.Sprintf("%s %s -> %s:%s", op.Op, from, op.ToMountId, op.ToPath)
+	case PlanPut:
+		return fmt.Sprintf("put %s:%s (%d bytes)", op.MountId, path.Join(op.Path, op.Name), len(op.Content))
+	case PlanNewFolder:
+		return fmt.Sprintf("mkdir %s:%s", op.MountId, path.Join(op.Path, op.Name))
+	}
+
+	return fmt.Sprintf("%s %s", op.Op, from)
+}
+
+// Plan records file operations instead of sending them. It implements
+// FileOperations, can be printed or serialized to JSON and is run with
+// Execute. Uploads are buffered in memory until then.
+type Plan struct {
+	Ops []PlanOp `json:"ops"`
+
+	mu sync.Mutex
+}
+
+func (p *Plan) add(op PlanOp) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Ops = append(p.Ops, op)
+}
+
+func (p *Plan) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lines := make([]string, len(p.Ops))
+
+	for i, op := range p.Ops {
+		lines[i] = op.String() + "\n"
+	}
+
+	return strings.Join(lines, "")
+}
+
+func (p *Plan) FilesDelete(mountId string, path string) (err error) {
+	return p.FilesDeleteWithOptions(mountId, path, nil)
+}
+
+func (p *Plan) FilesDeleteWithOptions(mountId string, path string, deleteOptions *DeleteOptions) (err error) {
+	p.add(PlanOp{Op: PlanDelete, MountId: mountId, Path: path, DeleteOptions: deleteOptions})
+	return
+}
+
+func (p *Plan) FilesNewFolder(mountId string, path string, name string) (err error) {
+	p.add(PlanOp{Op: PlanNewFolder, MountId: mountId, Path: path, Name: name})
+	return
+}
+
+func (p *Plan) FilesCopy(mountId string, path string, toMountId string, toPath string, options CopyOptions) (err error) {
+	p.add(PlanOp{Op: PlanCopy, MountId: mountId, Path: path, ToMountId: toMountId, ToPath: toPath, CopyOptions: &options})
+	return
+}
+
+func (p *Plan) FilesMove(mountId string, path string, toMountId string, toPath string) (err error) {
+	p.add(PlanOp{Op: PlanMove, MountId: mountId, Path: path, ToMountId: toMountId, ToPath: toPath})
+	return
+}
+
+func (p *Plan) FilesPut(mountId string, path string, name string, reader io.Reader) (newName string, err error) {
+	info, err := p.FilesPutWithOptions(mountId, path, name, reader, nil)
+
+	if err != nil {
+		return
+	}
+
+	return info.Name, nil
+}
+
+// FilesPutWithOptions reads the content and returns the info the file is
+// expected to have once uploaded.
+func (p *Plan) FilesPutWithOptions(mountId string, path string, name string, reader io.Reader, putOptions *PutOptions) (fileInfo *FileInfo, err error) {
+	content, err := ioutil.ReadAll(reader)
+
+	if err != nil {
+		return
+	}
+
+	p.add(PlanOp{Op: PlanPut, MountId: mountId, Path: path, Name: name, Content: content, PutOptions: putOptions})
+
+	hash := md5.Sum(content)
+
+	fileInfo = &FileInfo{
+		Name: name,
+		Type: "file",
+		Size: int64(len(content)),
+		Hash: hex.EncodeToString(hash[:]),
+	}
+
+	if putOptions != nil && putOptions.SetModified != nil {
+		fileInfo.Modified = *putOptions.SetModified
+	}
+
+	return
+}
+
+// Execute runs the recorded operations in order and stops at the first
+// error. done is the number of operations that succeeded, so the rest can be
+// retried with Ops[done:].
+func (p *Plan) Execute(c *KoofrClient) (done int, err error) {
+	p.mu.Lock()
+	ops := p.Ops
+	p.mu.Unlock()
+
+	for _, op := range ops {
+		if err = op.execute(c); err != nil {
+			return
+		}
+
+		done++
+	}
+
+	return
+}
+
+func (op PlanOp) execute(c *KoofrClient) (err error) {
+	switch op.Op {
+	case PlanDelete:
+		return c.FilesDeleteWithOptions(op.MountId, op.Path, op.DeleteOptions)
+	case PlanNewFolder:
+		return c.FilesNewFolder(op.MountId, op.Path, op.Name)
+	case PlanCopy:
+		options := CopyOptions{}
+
+		if op.CopyOptions != nil {
+			options = *op.CopyOptions
+		}
+
+		return c.FilesCopy(op.MountId, op.Path, op.ToMountId, op.ToPath, options)
+	case PlanMove:
+		return c.FilesMove(op.MountId, op.Path, op.ToMountId, op.ToPath)
+	case PlanPut:
+		_, err = c.FilesPutWithOptions(op.MountId, op.Path, op.Name, bytes.NewReader(op.Content), op.PutOptions)
+		return
+	}
+
+	return fmt.Errorf("Unknown plan operation: %s", op.Op)
+}
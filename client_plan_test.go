@@ -0,0 +1,55 @@
+package koofrclient_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientPlan", func() {
+	AfterEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/plan")
+	})
+
+	It("should record operations and execute them later", func() {
+		plan := &k.Plan{}
+		var ops k.FileOperations = plan
+
+		Expect(ops.FilesNewFolder(defaultMountId, rootPath, "plan")).To(Succeed())
+		info, err := ops.FilesPutWithOptions(defaultMountId, rootPath+"/plan", "file.txt", bytes.NewReader([]byte("content")), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size).To(Equal(int64(7)))
+		Expect(info.Hash).To(Equal("9a0364b9e99bb480dd25e1f0284c8555"))
+		Expect(ops.FilesCopy(defaultMountId, rootPath+"/plan/file.txt", defaultMountId, rootPath+"/plan/copy.txt", k.CopyOptions{})).To(Succeed())
+		Expect(ops.FilesDelete(defaultMountId, rootPath+"/plan/file.txt")).To(Succeed())
+
+		Expect(plan.Ops).To(HaveLen(4))
+		Expect(plan.String()).To(ContainSubstring("put " + defaultMountId + ":" + rootPath + "/plan/file.txt (7 bytes)\n"))
+
+		_, err = client.FilesInfo(defaultMountId, rootPath+"/plan")
+		Expect(err).To(HaveOccurred())
+
+		data, err := json.Marshal(plan)
+		Expect(err).NotTo(HaveOccurred())
+
+		loaded := &k.Plan{}
+		Expect(json.Unmarshal(data, loaded)).To(Succeed())
+
+		done, err := loaded.Execute(client)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done).To(Equal(4))
+
+		reader, err := client.FilesGet(defaultMountId, rootPath+"/plan/copy.txt")
+		Expect(err).NotTo(HaveOccurred())
+		content, _ := ioutil.ReadAll(reader)
+		reader.Close()
+		Expect(content).To(Equal([]byte("content")))
+
+		_, err = client.FilesInfo(defaultMountId, rootPath+"/plan/file.txt")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,157 @@
+package koofrclient
+
+import (
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/koofr/go-httpclient"
+)
+
+// Location is a file or folder on a mount.
+type Location struct {
+	MountId string
+	Path    string
+}
+
+type TransferOptions struct {
+	// Move deletes the source once it was transferred.
+	Move bool
+	// NoServerSide skips the server-side copy or move and always streams
+	// the content through the client.
+	NoServerSide bool
+	// NoVerify skips comparing the streamed content with the source hash.
+	NoVerify bool
+}
+
+// Transfer copies or moves a file or folder to dst, which must not exist.
+// The server-side FilesCopy or FilesMove is tried first. If that fails
+// between two different mounts (some mount types can not exchange data on
+// the server), the content is streamed with FilesGet and
+// FilesPutWithOptions instead, keeping the modified time and checking the
+// hash. For moves the source is deleted afterwards, only if it did not
+// change in the meantime. Folders are streamed file by file.
+func (c *KoofrClient) Transfer(src Location, dst Location, options *TransferOptions) (info FileInfo, err error) {
+	opts := TransferOptions{}
+
+	if options != nil {
+		opts = *options
+	}
+
+	srcInfo, err := c.FilesInfo(src.MountId, src.Path)
+
+	if err != nil {
+		return
+	}
+
+	if !opts.NoServerSide {
+		if opts.Move {
+			err = c.FilesMove(src.MountId, src.Path, dst.MountId, dst.Path)
+		} else {
+			err = c.FilesCopy(src.MountId, src.Path, dst.MountId, dst.Path, CopyOptions{SetModified: &srcInfo.Modified})
+		}
+
+		if err == nil {
+			return c.FilesInfo(dst.MountId, dst.Path)
+		}
+
+		if src.MountId == dst.MountId || !canStreamAfter(err) {
+			return
+		}
+	}
+
+	if err = c.streamTransfer(src, srcInfo, dst, opts); err != nil {
+		return
+	}
+
+	return c.FilesInfo(dst.MountId, dst.Path)
+}
+
+// canStreamAfter reports whether a failed server-side transfer may succeed
+// when streamed. Missing files and existing destinations fail either way.
+func canStreamAfter(err error) bool {
+	return !httpclient.IsInvalidStatusCode(err, http.StatusNotFound) &&
+		!httpclient.IsInvalidStatusCode(err, http.StatusConflict)
+}
+
+func (c *KoofrClient) streamTransfer(src Location, srcInfo FileInfo, dst Location, opts TransferOptions) (err error) {
+	if srcInfo.Type == "dir" {
+		return c.streamTransferDir(src, dst, opts)
+	}
+
+	reader, err := c.FilesGet(src.MountId, src.Path)
+
+	if err != nil {
+		return
+	}
+
+	defer reader.Close()
+
+	var body io.Reader = reader
+
+	h := newContentHash(srcInfo.Hash)
+
+	if opts.NoVerify {
+		h = nil
+	}
+
+	if h != nil {
+		body = io.TeeReader(reader, h)
+	}
+
+	dstInfo, err := c.FilesPutWithOptions(dst.MountId, path.Dir(dst.Path), path.Base(dst.Path), body, &PutOptions{
+		NoRename:      true,
+		SetModified:   &srcInfo.Modified,
+		ContentLength: &srcInfo.Size,
+	})
+
+	if err != nil {
+		return
+	}
+
+	if h != nil {
+		err = verifyContentHash(h, srcInfo.Hash)
+
+		if err == nil && newContentHash(dstInfo.Hash) != nil {
+			err = verifyContentHash(h, dstInfo.Hash)
+		}
+
+		if err != nil {
+			c.FilesDeleteWithOptions(dst.MountId, dst.Path, &DeleteOptions{RemoveIfHash: &dstInfo.Hash})
+			return
+		}
+	}
+
+	if opts.Move {
+		err = c.FilesDeleteWithOptions(src.MountId, src.Path, &DeleteOptions{RemoveIfHash: &srcInfo.Hash})
+	}
+
+	return
+}
+
+func (c *KoofrClient) streamTransferDir(src Location, dst Location, opts TransferOptions) (err error) {
+	if err = c.FilesNewFolder(dst.MountId, path.Dir(dst.Path), path.Base(dst.Path)); err != nil {
+		return
+	}
+
+	files, err := c.FilesList(src.MountId, src.Path)
+
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		childSrc := Location{src.MountId, path.Join(src.Path, file.Name)}
+		childDst := Location{dst.MountId, path.Join(dst.Path, file.Name)}
+
+		if err = c.streamTransfer(childSrc, file, childDst, opts); err != nil {
+			return
+		}
+	}
+
+	if opts.Move {
+		err = c.FilesDeleteWithOptions(src.MountId, src.Path, &DeleteOptions{RemoveIfEmpty: true})
+	}
+
+	return
+}
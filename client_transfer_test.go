@@ -0,0 +1,70 @@
+package koofrclient_test
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientTransfer", func() {
+	var mtime = int64(1562663291000)
+
+	BeforeEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/transfer")
+		err := client.FilesNewFolder(defaultMountId, rootPath, "transfer")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesPutWithOptions(defaultMountId, rootPath+"/transfer", "file.txt", bytes.NewReader([]byte("content")), &k.PutOptions{SetModified: &mtime})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		client.FilesDelete(defaultMountId, rootPath+"/transfer")
+	})
+
+	src := func(p string) k.Location {
+		return k.Location{MountId: defaultMountId, Path: rootPath + "/transfer" + p}
+	}
+
+	It("should copy on the server", func() {
+		info, err := client.Transfer(src("/file.txt"), src("/copy.txt"), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size).To(Equal(int64(7)))
+		Expect(info.Modified).To(Equal(mtime))
+	})
+
+	It("should stream a copy keeping the modified time", func() {
+		info, err := client.Transfer(src("/file.txt"), src("/copy.txt"), &k.TransferOptions{NoServerSide: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Modified).To(Equal(mtime))
+
+		reader, err := client.FilesGet(defaultMountId, rootPath+"/transfer/copy.txt")
+		Expect(err).NotTo(HaveOccurred())
+		data, _ := ioutil.ReadAll(reader)
+		reader.Close()
+		Expect(data).To(Equal([]byte("content")))
+	})
+
+	It("should stream a folder move", func() {
+		err := client.FilesNewFolder(defaultMountId, rootPath+"/transfer", "dst")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.Transfer(src(""), k.Location{MountId: defaultMountId, Path: rootPath + "/moved"}, &k.TransferOptions{Move: true, NoServerSide: true})
+		Expect(err).NotTo(HaveOccurred())
+		defer client.FilesDelete(defaultMountId, rootPath+"/moved")
+
+		_, err = client.FilesInfo(defaultMountId, rootPath+"/moved/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesInfo(defaultMountId, rootPath+"/moved/dst")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesInfo(defaultMountId, rootPath+"/transfer")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should not overwrite the destination", func() {
+		_, err := client.Transfer(src("/file.txt"), src("/file.txt"), &k.TransferOptions{NoServerSide: true})
+		Expect(err).To(Equal(k.ErrCannotOverwrite))
+	})
+})
@@ -0,0 +1,302 @@
+package koofrclient
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/koofr/go-httpclient"
+)
+
+// ConflictPolicy decides what TransferTree does when a destination file
+// already exists.
+type ConflictPolicy string
+
+const (
+	ConflictFail             ConflictPolicy = "fail"
+	ConflictSkip             ConflictPolicy = "skip"
+	ConflictOverwrite        ConflictPolicy = "overwrite"
+	ConflictOverwriteIfNewer ConflictPolicy = "overwrite-if-newer"
+	ConflictRename           ConflictPolicy = "rename"
+)
+
+const (
+	TreeActionCopy      = "copy"
+	TreeActionMove      = "move"
+	TreeActionSkip      = "skip"
+	TreeActionRemoveDir = "rmdir"
+)
+
+var ErrInvalidConflictPolicy = fmt.Errorf("Invalid conflict policy")
+
+// ConflictError is returned by TransferTree with ConflictFail when the
+// destination of Src already exists.
+type ConflictError struct {
+	Src Location
+	Dst Location
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("Destination already exists: %s", e.Dst)
+}
+
+func (l Location) String() string {
+	return l.MountId + ":" + l.Path
+}
+
+type TransferTreeOptions struct {
+	TransferOptions
+	// Conflict is the policy for existing destination files. Defaults to
+	// ConflictFail.
+	Conflict ConflictPolicy
+	// DryRun only reports the actions that would be taken.
+	DryRun bool
+}
+
+// TreeAction is a single step of TransferTree. Overwrite is set when an
+// existing file at Dst is replaced.
+type TreeAction struct {
+	Action    string   `json:"action"`
+	Src       Location `json:"src"`
+	Dst       Location `json:"dst"`
+	Overwrite bool     `json:"overwrite,omitempty"`
+}
+
+func (a TreeAction) String() string {
+	switch a.Action {
+	case TreeActionSkip:
+		return fmt.Sprintf("skip %s (%s exists)", a.Src, a.Dst)
+	case TreeActionRemoveDir:
+		return fmt.Sprintf("rmdir %s", a.Src)
+	}
+
+	s := fmt.Sprintf("%s %s -> %s", a.Action, a.Src, a.Dst)
+
+	if a.Overwrite {
+		s += " (overwrite)"
+	}
+
+	return s
+}
+
+// TransferTree copies or moves src to dst like Transfer, but merges folders
+// into existing destination folders and resolves existing files with
+// options.Conflict. Missing destinations are transferred whole, so a
+// folder that does not exist yet is still copied in a single server-side
+// request. Files being overwritten are kept until the new content was
+// transferred next to them, and are replaced only if they did not change
+// since they were checked. When moving, source folders are removed once
+// everything in them was moved.
+//
+// The returned actions are the ones that were completed, or with DryRun the
+// ones that would be taken.
+func (c *KoofrClient) TransferTree(src Location, dst Location, options *TransferTreeOptions) (actions []TreeAction, err error) {
+	opts := TransferTreeOptions{}
+
+	if options != nil {
+		opts = *options
+	}
+
+	switch opts.Conflict {
+	case "":
+		opts.Conflict = ConflictFail
+	case ConflictFail, ConflictSkip, ConflictOverwrite, ConflictOverwriteIfNewer, ConflictRename:
+	default:
+		return nil, ErrInvalidConflictPolicy
+	}
+
+	srcInfo, err := c.FilesInfo(src.MountId, src.Path)
+
+	if err != nil {
+		return
+	}
+
+	t := &treeTransfer{
+		client: c,
+		opts:   opts,
+	}
+
+	_, err = t.transfer(src, srcInfo, dst)
+
+	return t.actions, err
+}
+
+type treeTransfer struct {
+	client  *KoofrClient
+	opts    TransferTreeOptions
+	actions []TreeAction
+}
+
+// transfer handles a single entry. kept reports whether anything was left
+// behind at src when moving.
+func (t *treeTransfer) transfer(src Location, srcInfo FileInfo, dst Location) (kept bool, err error) {
+	dstInfo, err := t.client.FilesInfo(dst.MountId, dst.Path)
+
+	if httpclient.IsInvalidStatusCode(err, http.StatusNotFound) {
+		return false, t.transferTo(src, dst, nil)
+	}
+
+	if err != nil {
+		return
+	}
+
+	if srcInfo.Type == "dir" && dstInfo.Type == "dir" {
+		return t.merge(src, dst)
+	}
+
+	policy := t.opts.Conflict
+
+	if srcInfo.Type == "dir" || dstInfo.Type == "dir" {
+		// a file and a folder are never overwritten by one another
+		if policy == ConflictOverwrite || policy == ConflictOverwriteIfNewer {
+			policy = ConflictFail
+		}
+	}
+
+	if policy == ConflictOverwriteIfNewer {
+		policy = ConflictSkip
+
+		if srcInfo.Modified > dstInfo.Modified {
+			policy = ConflictOverwrite
+		}
+	}
+
+	switch policy {
+	case ConflictSkip:
+		return true, t.run(TreeAction{Action: TreeActionSkip, Src: src, Dst: dst}, nil)
+	case ConflictOverwrite:
+		return false, t.transferTo(src, dst, &dstInfo)
+	case ConflictRename:
+		dst, err = t.freeLocation(dst)
+
+		if err != nil {
+			return
+		}
+
+		return false, t.transferTo(src, dst, nil)
+	}
+
+	return false, &ConflictError{Src: src, Dst: dst}
+}
+
+func (t *treeTransfer) transferTo(src Location, dst Location, existing *FileInfo) (err error) {
+	action := TreeAction{
+		Action:    TreeActionCopy,
+		Src:       src,
+		Dst:       dst,
+		Overwrite: existing != nil,
+	}
+
+	if t.opts.Move {
+		action.Action = TreeActionMove
+	}
+
+	return t.run(action, existing)
+}
+
+func (t *treeTransfer) merge(src Location, dst Location) (kept bool, err error) {
+	files, err := t.client.FilesList(src.MountId, src.Path)
+
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		childSrc := Location{src.MountId, path.Join(src.Path, file.Name)}
+		childDst := Location{dst.MountId, path.Join(dst.Path, file.Name)}
+
+		childKept, err := t.transfer(childSrc, file, childDst)
+
+		if err != nil {
+			return kept, err
+		}
+
+		kept = kept || childKept
+	}
+
+	if t.opts.Move && !kept {
+		err = t.run(TreeAction{Action: TreeActionRemoveDir, Src: src}, nil)
+	}
+
+	return
+}
+
+// freeLocation finds the first "name (n).ext" next to dst that does not
+// exist, the same way the server renames uploads.
+func (t *treeTransfer) freeLocation(dst Location) (free Location, err error) {
+	name := path.Base(dst.Path)
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	for i := 1; ; i++ {
+		free = Location{dst.MountId, path.Join(path.Dir(dst.Path), fmt.Sprintf("%s (%d)%s", base, i, ext))}
+
+		_, err = t.client.FilesInfo(free.MountId, free.Path)
+
+		if httpclient.IsInvalidStatusCode(err, http.StatusNotFound) {
+			return free, nil
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// run executes action unless this is a dry run and records it. existing is
+// the destination being overwritten.
+func (t *treeTransfer) run(action TreeAction, existing *FileInfo) (err error) {
+	if !t.opts.DryRun {
+		switch action.Action {
+		case TreeActionSkip:
+		case TreeActionRemoveDir:
+			err = t.client.FilesDeleteWithOptions(action.Src.MountId, action.Src.Path, &DeleteOptions{RemoveIfEmpty: true})
+		default:
+			if existing != nil {
+				err = t.replace(action.Src, action.Dst, existing.Hash)
+			} else {
+				_, err = t.client.Transfer(action.Src, action.Dst, &t.opts.TransferOptions)
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	t.actions = append(t.actions, action)
+
+	return
+}
+
+// replace transfers src to a hidden name next to dst first, so a failed
+// transfer leaves dst untouched, and then swaps it in if dst still has the
+// given hash.
+func (t *treeTransfer) replace(src Location, dst Location, hash string) (err error) {
+	tmp := Location{dst.MountId, path.Join(path.Dir(dst.Path), fmt.Sprintf(".%s.%d.transfer", path.Base(dst.Path), time.Now().UnixNano()))}
+
+	if _, err = t.client.Transfer(src, tmp, &t.opts.TransferOptions); err != nil {
+		return
+	}
+
+	if err = t.client.FilesDeleteWithOptions(dst.MountId, dst.Path, &DeleteOptions{RemoveIfHash: &hash}); err != nil {
+		if !t.opts.Move {
+			t.client.FilesDelete(tmp.MountId, tmp.Path)
+			return
+		}
+
+		if _, restoreErr := t.client.Transfer(tmp, src, &t.opts.TransferOptions); restoreErr != nil {
+			return fmt.Errorf("Content was left at %s: %s", tmp, err)
+		}
+
+		return
+	}
+
+	if err = t.client.FilesMove(tmp.MountId, tmp.Path, dst.MountId, dst.Path); err != nil {
+		return fmt.Errorf("Content was left at %s: %s", tmp, err)
+	}
+
+	return
+}
@@ -0,0 +1,91 @@
+package koofrclient_test
+
+import (
+	"bytes"
+
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientTransferTree", func() {
+	var base string
+
+	put := func(dir string, name string, content string, modified int64) {
+		_, err := client.FilesPutWithOptions(defaultMountId, base+dir, name, bytes.NewReader([]byte(content)), &k.PutOptions{SetModified: &modified})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	loc := func(p string) k.Location {
+		return k.Location{MountId: defaultMountId, Path: base + p}
+	}
+
+	BeforeEach(func() {
+		base = rootPath + "/tree"
+		client.FilesDelete(defaultMountId, base)
+		Expect(client.FilesNewFolder(defaultMountId, rootPath, "tree")).To(Succeed())
+		Expect(client.FilesNewFolder(defaultMountId, base, "src")).To(Succeed())
+		Expect(client.FilesNewFolder(defaultMountId, base, "dst")).To(Succeed())
+		put("/src", "new.txt", "new", 2000)
+		put("/src", "old.txt", "old", 1000)
+		put("/dst", "new.txt", "dst", 1000)
+		put("/dst", "old.txt", "dst", 2000)
+	})
+
+	AfterEach(func() {
+		client.FilesDelete(defaultMountId, base)
+	})
+
+	It("should fail on conflicts by default", func() {
+		_, err := client.TransferTree(loc("/src"), loc("/dst"), nil)
+		Expect(err).To(BeAssignableToTypeOf(&k.ConflictError{}))
+	})
+
+	It("should report planned actions in a dry run", func() {
+		actions, err := client.TransferTree(loc("/src"), loc("/dst"), &k.TransferTreeOptions{
+			Conflict: k.ConflictOverwriteIfNewer,
+			DryRun:   true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions).To(Equal([]k.TreeAction{
+			{Action: k.TreeActionCopy, Src: loc("/src/new.txt"), Dst: loc("/dst/new.txt"), Overwrite: true},
+			{Action: k.TreeActionSkip, Src: loc("/src/old.txt"), Dst: loc("/dst/old.txt")},
+		}))
+
+		info, err := client.FilesInfo(defaultMountId, base+"/dst/new.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size).To(Equal(int64(3)))
+		Expect(info.Modified).To(Equal(int64(1000)))
+	})
+
+	It("should overwrite newer files in place", func() {
+		_, err := client.TransferTree(loc("/src"), loc("/dst"), &k.TransferTreeOptions{
+			Conflict: k.ConflictOverwriteIfNewer,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := client.FilesInfo(defaultMountId, base+"/dst/new.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Modified).To(Equal(int64(2000)))
+
+		files, err := client.FilesList(defaultMountId, base+"/dst")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(2))
+	})
+
+	It("should move with renames and remove the source", func() {
+		actions, err := client.TransferTree(loc("/src"), loc("/dst"), &k.TransferTreeOptions{
+			TransferOptions: k.TransferOptions{Move: true},
+			Conflict:        k.ConflictRename,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions).To(HaveLen(3))
+		Expect(actions[0].Dst).To(Equal(loc("/dst/new (1).txt")))
+		Expect(actions[2].Action).To(Equal(k.TreeActionRemoveDir))
+
+		_, err = client.FilesInfo(defaultMountId, base+"/dst/old (1).txt")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesInfo(defaultMountId, base+"/src")
+		Expect(err).To(HaveOccurred())
+	})
+})
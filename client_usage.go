@@ -0,0 +1,149 @@
+package koofrclient
+
+import (
+	"mime"
+	"path"
+	"sort"
+)
+
+type MountUsage struct {
+	MountId    string    `json:"mountId"`
+	Name       string    `json:"name"`
+	Type       MountType `json:"type"`
+	IsPrimary  bool      `json:"isPrimary"`
+	IsShared   bool      `json:"isShared"`
+	SpaceTotal int64     `json:"spaceTotal"`
+	SpaceUsed  int64     `json:"spaceUsed"`
+	SpaceFree  int64     `json:"spaceFree"`
+}
+
+// Usage holds the space of every mount and the account totals, which are
+// summed over the user's devices so shared and exported mounts are not
+// counted twice.
+type Usage struct {
+	Mounts     []MountUsage `json:"mounts"`
+	SpaceTotal int64        `json:"spaceTotal"`
+	SpaceUsed  int64        `json:"spaceUsed"`
+	SpaceFree  int64        `json:"spaceFree"`
+}
+
+func (c *KoofrClient) Usage() (usage Usage, err error) {
+	mounts, err := c.Mounts()
+
+	if err != nil {
+		return
+	}
+
+	devices, err := c.Devices()
+
+	if err != nil {
+		return
+	}
+
+	usage.Mounts = make([]MountUsage, len(mounts))
+
+	for i, m := range mounts {
+		usage.Mounts[i] = MountUsage{
+			MountId:    m.Id,
+			Name:       m.Name,
+			Type:       m.Type,
+			IsPrimary:  m.IsPrimary,
+			IsShared:   m.IsShared,
+			SpaceTotal: m.SpaceTotal,
+			SpaceUsed:  m.SpaceUsed,
+			SpaceFree:  m.SpaceFree(),
+		}
+	}
+
+	for _, d := range devices {
+		usage.SpaceTotal += d.SpaceTotal
+		usage.SpaceUsed += d.SpaceUsed
+		usage.SpaceFree += d.SpaceFree
+	}
+
+	return
+}
+
+type DiskUsageEntry struct {
+	Size  int64 `json:"size"`
+	Files int64 `json:"files"`
+}
+
+// DiskUsage is the space taken by a folder, including all its subfolders.
+// ContentTypes are keyed by media type, without parameters such as charset.
+type DiskUsage struct {
+	Path         string                    `json:"path"`
+	Size         int64                     `json:"size"`
+	Files        int64                     `json:"files"`
+	Folders      int64                     `json:"folders"`
+	ContentTypes map[string]DiskUsageEntry `json:"contentTypes"`
+	// Children are the subfolders, largest first.
+	Children []*DiskUsage `json:"children"`
+}
+
+// DiskUsage sums file sizes under path by subfolder and by content type,
+// like du.
+func (c *KoofrClient) DiskUsage(mountId string, path string) (usage *DiskUsage, err error) {
+	tree, err := c.FilesTree(mountId, path)
+
+	if err != nil {
+		return
+	}
+
+	return diskUsage(path, &tree), nil
+}
+
+func diskUsage(p string, tree *FileTree) *DiskUsage {
+	usage := &DiskUsage{
+		Path:         p,
+		ContentTypes: map[string]DiskUsageEntry{},
+		Children:     []*DiskUsage{},
+	}
+
+	if tree.Type != "dir" {
+		usage.add(tree.ContentType, tree.Size)
+		return usage
+	}
+
+	for _, child := range tree.Children {
+		if child.Type != "dir" {
+			usage.add(child.ContentType, child.Size)
+			continue
+		}
+
+		sub := diskUsage(path.Join(p, child.Name), child)
+
+		usage.Size += sub.Size
+		usage.Files += sub.Files
+		usage.Folders += sub.Folders + 1
+
+		for contentType, entry := range sub.ContentTypes {
+			total := usage.ContentTypes[contentType]
+			total.Size += entry.Size
+			total.Files += entry.Files
+			usage.ContentTypes[contentType] = total
+		}
+
+		usage.Children = append(usage.Children, sub)
+	}
+
+	sort.SliceStable(usage.Children, func(i, j int) bool {
+		return usage.Children[i].Size > usage.Children[j].Size
+	})
+
+	return usage
+}
+
+func (u *DiskUsage) add(contentType string, size int64) {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+
+	u.Size += size
+	u.Files++
+
+	entry := u.ContentTypes[contentType]
+	entry.Size += size
+	entry.Files++
+	u.ContentTypes[contentType] = entry
+}
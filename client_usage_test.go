@@ -0,0 +1,43 @@
+package koofrclient_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientUsage", func() {
+	It("should get usage of all mounts", func() {
+		usage, err := client.Usage()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(usage.Mounts).NotTo(BeEmpty())
+		Expect(usage.SpaceTotal).To(BeNumerically(">", 0))
+		for _, m := range usage.Mounts {
+			Expect(m.SpaceFree).To(BeNumerically(">=", 0))
+		}
+	})
+
+	It("should sum disk usage by folder and content type", func() {
+		client.FilesDelete(defaultMountId, rootPath+"/du")
+		err := client.FilesNewFolder(defaultMountId, rootPath, "du")
+		Expect(err).NotTo(HaveOccurred())
+		defer client.FilesDelete(defaultMountId, rootPath+"/du")
+		err = client.FilesNewFolder(defaultMountId, rootPath+"/du", "sub")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesPut(defaultMountId, rootPath+"/du", "a.txt", bytes.NewReader([]byte("12345")))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesPut(defaultMountId, rootPath+"/du/sub", "b.txt", bytes.NewReader([]byte("123")))
+		Expect(err).NotTo(HaveOccurred())
+
+		usage, err := client.DiskUsage(defaultMountId, rootPath+"/du")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(usage.Size).To(Equal(int64(8)))
+		Expect(usage.Files).To(Equal(int64(2)))
+		Expect(usage.Folders).To(Equal(int64(1)))
+		Expect(usage.ContentTypes["text/plain"].Files).To(Equal(int64(2)))
+		Expect(usage.Children).To(HaveLen(1))
+		Expect(usage.Children[0].Path).To(Equal(rootPath + "/du/sub"))
+		Expect(usage.Children[0].Size).To(Equal(int64(3)))
+	})
+})
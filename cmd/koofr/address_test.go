@@ -0,0 +1,65 @@
+package main
+
+import (
+	koofrclient "github.com/koofr/go-koofrclient"
+	"github.com/koofr/go-koofrclient/koofrtest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Address", func() {
+	var server *koofrtest.Server
+	var app *App
+
+	BeforeEach(func() {
+		server = koofrtest.NewServer()
+		server.AddMount(koofrclient.Mount{Id: "dropbox", Name: "Dropbox"})
+
+		client := server.Client()
+		app = &App{
+			Client: client,
+			Mounts: koofrclient.NewMountResolver(client),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	resolve := func(address string) (string, string) {
+		mountId, p, err := app.Mounts.Resolve(address)
+		Expect(err).NotTo(HaveOccurred())
+		return mountId, p
+	}
+
+	It("should split mount name and path", func() {
+		mountId, p := resolve("Dropbox:/docs/a.txt")
+		Expect(mountId).To(Equal("dropbox"))
+		Expect(p).To(Equal("/docs/a.txt"))
+	})
+
+	It("should use the primary mount for absolute paths", func() {
+		mountId, p := resolve("/docs/a:b.txt")
+		Expect(mountId).To(Equal(koofrtest.PrimaryMountId))
+		Expect(p).To(Equal("/docs/a:b.txt"))
+
+		mountId, _ = resolve("primary:/")
+		Expect(mountId).To(Equal(koofrtest.PrimaryMountId))
+	})
+
+	It("should clean paths", func() {
+		_, p := resolve("Dropbox:docs//x/../a.txt")
+		Expect(p).To(Equal("/docs/a.txt"))
+		_, p = resolve("Dropbox:")
+		Expect(p).To(Equal("/"))
+	})
+
+	It("should prefer a mount named primary over the primary mount", func() {
+		server.AddMount(koofrclient.Mount{Id: "other", Name: "primary"})
+
+		mountId, _ := resolve("primary:/")
+		Expect(mountId).To(Equal("other"))
+		mountId, _ = resolve("/")
+		Expect(mountId).To(Equal(koofrtest.PrimaryMountId))
+	})
+})
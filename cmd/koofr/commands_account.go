@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+func init() {
+	register(&Command{
+		Name:  "login",
+		Usage: "login [-email email]",
+		Help:  "authenticate and store the token in the config file",
+		Run:   runLogin,
+	})
+	register(&Command{
+		Name:  "whoami",
+		Usage: "whoami",
+		Help:  "show the current user",
+		Auth:  true,
+		Run:   runWhoami,
+	})
+	register(&Command{
+		Name:  "mounts",
+		Usage: "mounts",
+		Help:  "list mounts",
+		Auth:  true,
+		Run:   runMounts,
+	})
+	register(&Command{
+		Name:  "devices",
+		Usage: "devices",
+		Help:  "list devices",
+		Auth:  true,
+		Run:   runDevices,
+	})
+	register(&Command{
+		Name:  "shared",
+		Usage: "shared",
+		Help:  "list shared files, links and receivers",
+		Auth:  true,
+		Run:   runShared,
+	})
+}
+
+func prompt(label string, secret bool) (value string, err error) {
+	fmt.Fprint(os.Stderr, label)
+
+	if secret && term.IsTerminal(int(os.Stdin.Fd())) {
+		data, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return string(data), err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+
+	if err != nil && line == "" {
+		return
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func runLogin(app *App, args []string) (err error) {
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	email := flags.String("email", os.Getenv("KOOFR_EMAIL"), "account email")
+	flags.Parse(args)
+
+	if *email == "" {
+		if *email, err = prompt("Email: ", false); err != nil {
+			return
+		}
+	}
+
+	password := os.Getenv("KOOFR_PASSWORD")
+
+	if password == "" {
+		if password, err = prompt("Password: ", true); err != nil {
+			return
+		}
+	}
+
+	if err = app.Client.Authenticate(*email, password); err != nil {
+		return
+	}
+
+	app.Config.Token = app.Client.GetToken()
+	app.Config.UserID = app.Client.GetUserID()
+
+	if err = app.Config.Save(app.ConfigPath); err != nil {
+		return
+	}
+
+	if app.JSON {
+		return app.PrintJSON(map[string]string{"userId": app.Config.UserID})
+	}
+
+	fmt.Fprintf(app.Out, "Logged in, token saved to %s\n", app.ConfigPath)
+
+	return
+}
+
+func runWhoami(app *App, args []string) (err error) {
+	user, err := app.Client.UserInfo()
+
+	if err != nil {
+		return
+	}
+
+	if app.JSON {
+		return app.PrintJSON(user)
+	}
+
+	_, err = fmt.Fprintf(app.Out, "%s %s <%s>\n", user.FirstName, user.LastName, user.Email)
+
+	return
+}
+
+func runMounts(app *App, args []string) (err error) {
+	mounts, err := app.Client.Mounts()
+
+	if err != nil {
+		return
+	}
+
+	rows := make([][]interface{}, len(mounts))
+
+	for i, m := range mounts {
+		primary := ""
+		if m.IsPrimary {
+			primary = "*"
+		}
+		rows[i] = []interface{}{m.Name + primary, m.Type, formatSize(m.SpaceUsed), formatSize(m.SpaceTotal), m.Id}
+	}
+
+	return app.PrintTable(mounts, []interface{}{"NAME", "TYPE", "USED", "TOTAL", "ID"}, rows)
+}
+
+func runDevices(app *App, args []string) (err error) {
+	devices, err := app.Client.Devices()
+
+	if err != nil {
+		return
+	}
+
+	rows := make([][]interface{}, len(devices))
+
+	for i, d := range devices {
+		rows[i] = []interface{}{d.Name, d.Status, formatSize(d.SpaceUsed), formatSize(d.SpaceTotal), d.Id}
+	}
+
+	return app.PrintTable(devices, []interface{}{"NAME", "STATUS", "USED", "TOTAL", "ID"}, rows)
+}
+
+func runShared(app *App, args []string) (err error) {
+	shared, err := app.Client.Shared()
+
+	if err != nil {
+		return
+	}
+
+	rows := make([][]interface{}, len(shared))
+
+	for i, s := range shared {
+		rows[i] = []interface{}{s.Name, s.Type, s.Mount.Name, formatSize(s.Size)}
+	}
+
+	return app.PrintTable(shared, []interface{}{"NAME", "TYPE", "MOUNT", "SIZE"}, rows)
+}
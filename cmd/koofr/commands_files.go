@@ -0,0 +1,440 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+func init() {
+	register(&Command{
+		Name:  "ls",
+		Usage: "ls <remote>",
+		Help:  "list a folder",
+		Auth:  true,
+		Run:   runLs,
+	})
+	register(&Command{
+		Name:  "tree",
+		Usage: "tree <remote>",
+		Help:  "show a folder tree",
+		Auth:  true,
+		Run:   runTree,
+	})
+	register(&Command{
+		Name:  "stat",
+		Usage: "stat <remote>",
+		Help:  "show file info",
+		Auth:  true,
+		Run:   runStat,
+	})
+	register(&Command{
+		Name:  "get",
+		Usage: "get <remote> [local|-]",
+		Help:  "download a file, resuming an interrupted download",
+		Auth:  true,
+		Run:   runGet,
+	})
+	register(&Command{
+		Name:  "put",
+		Usage: "put [-overwrite] <local|-> <remote folder> [name]",
+		Help:  "upload a file",
+		Auth:  true,
+		Run:   runPut,
+	})
+	register(&Command{
+		Name:  "mkdir",
+		Usage: "mkdir <remote>",
+		Help:  "create a folder",
+		Auth:  true,
+		Run:   runMkdir,
+	})
+	register(&Command{
+		Name:  "cp",
+		Usage: "cp [-n] [-conflict p] <remote> <remote>",
+		Help:  "copy a file or folder",
+		Auth:  true,
+		Run:   runCp,
+	})
+	register(&Command{
+		Name:  "mv",
+		Usage: "mv [-n] [-conflict p] <remote> <remote>",
+		Help:  "move a file or folder",
+		Auth:  true,
+		Run:   runMv,
+	})
+	register(&Command{
+		Name:  "rm",
+		Usage: "rm [filters] <remote>",
+		Help:  "delete a file or folder",
+		Auth:  true,
+		Run:   runRm,
+	})
+}
+
+func expectArgs(args []string, min int, max int) error {
+	if len(args) < min || len(args) > max {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	return nil
+}
+
+func fileRow(info koofrclient.FileInfo) []interface{} {
+	size := "-"
+	if info.Type == "file" {
+		size = formatSize(info.Size)
+	}
+	return []interface{}{info.Type, size, formatTime(info.Modified), info.Name}
+}
+
+func runLs(app *App, args []string) (err error) {
+	if len(args) == 0 {
+		args = []string{"/"}
+	}
+
+	if err = expectArgs(args, 1, 1); err != nil {
+		return
+	}
+
+	mountId, p, err := app.Mounts.Resolve(args[0])
+
+	if err != nil {
+		return
+	}
+
+	files, err := app.Client.FilesList(mountId, p)
+
+	if err != nil {
+		return
+	}
+
+	rows := make([][]interface{}, len(files))
+
+	for i, info := range files {
+		rows[i] = fileRow(info)
+	}
+
+	return app.PrintTable(files, []interface{}{"TYPE", "SIZE", "MODIFIED", "NAME"}, rows)
+}
+
+func runTree(app *App, args []string) (err error) {
+	if err = expectArgs(args, 1, 1); err != nil {
+		return
+	}
+
+	mountId, p, err := app.Mounts.Resolve(args[0])
+
+	if err != nil {
+		return
+	}
+
+	tree, err := app.Client.FilesTree(mountId, p)
+
+	if err != nil {
+		return
+	}
+
+	if app.JSON {
+		return app.PrintJSON(tree)
+	}
+
+	printTree(app.Out, &tree, 0)
+
+	return
+}
+
+func printTree(w io.Writer, tree *koofrclient.FileTree, depth int) {
+	name := tree.Name
+	if tree.Type == "dir" {
+		name += "/"
+	}
+
+	fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", depth), name)
+
+	for _, child := range tree.Children {
+		printTree(w, child, depth+1)
+	}
+}
+
+func runStat(app *App, args []string) (err error) {
+	if err = expectArgs(args, 1, 1); err != nil {
+		return
+	}
+
+	mountId, p, err := app.Mounts.Resolve(args[0])
+
+	if err != nil {
+		return
+	}
+
+	info, err := app.Client.FilesInfo(mountId, p)
+
+	if err != nil {
+		return
+	}
+
+	if app.JSON {
+		return app.PrintJSON(info)
+	}
+
+	fmt.Fprintf(app.Out, "Name:         %s\n", info.Name)
+	fmt.Fprintf(app.Out, "Type:         %s\n", info.Type)
+	fmt.Fprintf(app.Out, "Size:         %d\n", info.Size)
+	fmt.Fprintf(app.Out, "Modified:     %s\n", formatTime(info.Modified))
+	fmt.Fprintf(app.Out, "Content type: %s\n", info.ContentType)
+	fmt.Fprintf(app.Out, "Hash:         %s\n", info.Hash)
+
+	return
+}
+
+func runGet(app *App, args []string) (err error) {
+	if err = expectArgs(args, 1, 2); err != nil {
+		return
+	}
+
+	mountId, p, err := app.Mounts.Resolve(args[0])
+
+	if err != nil {
+		return
+	}
+
+	local := path.Base(p)
+	if len(args) == 2 {
+		local = args[1]
+	}
+
+	if local == "-" {
+		reader, err := app.Client.FilesGet(mountId, p)
+
+		if err != nil {
+			return err
+		}
+
+		defer reader.Close()
+
+		_, err = io.Copy(app.Out, reader)
+
+		return err
+	}
+
+	if fi, statErr := os.Stat(local); statErr == nil && fi.IsDir() {
+		local = filepath.Join(local, path.Base(p))
+	}
+
+	_, err = app.Client.FilesDownloadFile(mountId, p, local, nil)
+
+	return
+}
+
+func runPut(app *App, args []string) (err error) {
+	flags := flag.NewFlagSet("put", flag.ExitOnError)
+	overwrite := flags.Bool("overwrite", false, "overwrite an existing file instead of renaming")
+	flags.Parse(args)
+	args = flags.Args()
+
+	if err = expectArgs(args, 2, 3); err != nil {
+		return
+	}
+
+	mountId, p, err := app.Mounts.Resolve(args[1])
+
+	if err != nil {
+		return
+	}
+
+	name := ""
+
+	if len(args) == 3 {
+		name = args[2]
+	}
+
+	putOptions := koofrclient.PutOptions{
+		ForceOverwrite: *overwrite,
+	}
+
+	var info *koofrclient.FileInfo
+
+	if args[0] == "-" {
+		if name == "" {
+			return fmt.Errorf("file name is required when uploading from stdin")
+		}
+
+		info, err = app.Client.FilesPutWithOptions(mountId, p, name, os.Stdin, &putOptions)
+	} else {
+		info, err = app.Client.FilesPutFile(mountId, p, args[0], &koofrclient.PutFileOptions{
+			PutOptions: putOptions,
+			Name:       name,
+			Retries:    2,
+		})
+	}
+
+	if err != nil {
+		return
+	}
+
+	if app.JSON {
+		return app.PrintJSON(info)
+	}
+
+	_, err = fmt.Fprintln(app.Out, path.Join(p, info.Name))
+
+	return
+}
+
+func runMkdir(app *App, args []string) (err error) {
+	if err = expectArgs(args, 1, 1); err != nil {
+		return
+	}
+
+	mountId, p, err := app.Mounts.Resolve(args[0])
+
+	if err != nil {
+		return
+	}
+
+	if p == "/" {
+		return fmt.Errorf("cannot create mount root")
+	}
+
+	return app.Client.FilesNewFolder(mountId, path.Dir(p), path.Base(p))
+}
+
+func runCp(app *App, args []string) (err error) {
+	return runTransfer(app, "cp", args, false)
+}
+
+func runMv(app *App, args []string) (err error) {
+	return runTransfer(app, "mv", args, true)
+}
+
+func runTransfer(app *App, name string, args []string, move bool) (err error) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	conflict := flags.String("conflict", "", "merge into an existing folder, resolving existing files with fail, skip, overwrite, overwrite-if-newer or rename")
+	dryRun := flags.Bool("n", false, "only print what would be done")
+	flags.Parse(args)
+	args = flags.Args()
+
+	if err = expectArgs(args, 2, 2); err != nil {
+		return
+	}
+
+	mountId, p, err := app.Mounts.Resolve(args[0])
+
+	if err != nil {
+		return
+	}
+
+	toMountId, toPath, err := app.Mounts.Resolve(args[1])
+
+	if err != nil {
+		return
+	}
+
+	src := koofrclient.Location{MountId: mountId, Path: p}
+	dst := koofrclient.Location{MountId: toMountId, Path: toPath}
+
+	if *conflict == "" && !*dryRun {
+		_, err = app.Client.Transfer(src, dst, &koofrclient.TransferOptions{
+			Move: move,
+		})
+
+		return
+	}
+
+	actions, err := app.Client.TransferTree(src, dst, &koofrclient.TransferTreeOptions{
+		TransferOptions: koofrclient.TransferOptions{
+			Move: move,
+		},
+		Conflict: koofrclient.ConflictPolicy(*conflict),
+		DryRun:   *dryRun,
+	})
+
+	if app.JSON {
+		if err != nil {
+			return
+		}
+
+		return app.PrintJSON(actions)
+	}
+
+	for _, action := range actions {
+		fmt.Fprintln(app.Out, action)
+	}
+
+	return
+}
+
+func runRm(app *App, args []string) (err error) {
+	flags := flag.NewFlagSet("rm", flag.ExitOnError)
+	include := flags.String("include", "", "only delete files matching these comma-separated patterns")
+	exclude := flags.String("exclude", "", "keep files and folders matching these comma-separated patterns")
+	olderThan := flags.Duration("older-than", 0, "only delete files modified longer ago than this")
+	largerThan := flags.Int64("larger-than", -1, "only delete files larger than this many bytes")
+	flags.Parse(args)
+	args = flags.Args()
+
+	if err = expectArgs(args, 1, 1); err != nil {
+		return
+	}
+
+	mountId, p, err := app.Mounts.Resolve(args[0])
+
+	if err != nil {
+		return
+	}
+
+	if p == "/" {
+		return fmt.Errorf("refusing to delete mount root")
+	}
+
+	if *include == "" && *exclude == "" && *olderThan == 0 && *largerThan < 0 {
+		return app.Client.FilesDelete(mountId, p)
+	}
+
+	options := &koofrclient.RemoveAllOptions{
+		Include: splitPatterns(*include),
+		Exclude: splitPatterns(*exclude),
+	}
+
+	if *olderThan > 0 {
+		before := time.Now().Add(-*olderThan).UnixNano() / int64(time.Millisecond)
+		options.ModifiedBefore = &before
+	}
+
+	if *largerThan >= 0 {
+		options.LargerThan = largerThan
+	}
+
+	result, err := app.Client.FilesRemoveAll(mountId, p, options)
+
+	if result != nil && !app.JSON {
+		for _, info := range result.Removed {
+			fmt.Fprintln(app.Out, "removed", info.Path)
+		}
+
+		for _, info := range result.Changed {
+			fmt.Fprintln(app.Out, "kept changed", info.Path)
+		}
+	}
+
+	if err != nil || !app.JSON {
+		return
+	}
+
+	return app.PrintJSON(result)
+}
+
+func splitPatterns(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
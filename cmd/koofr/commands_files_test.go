@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+	"github.com/koofr/go-koofrclient/koofrtest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CommandsFiles", func() {
+	var server *koofrtest.Server
+	var out *bytes.Buffer
+	var app *App
+
+	BeforeEach(func() {
+		server = koofrtest.NewServer()
+		server.AddMount(koofrclient.Mount{Id: "dropbox", Name: "Dropbox"})
+		server.PutFile(koofrtest.PrimaryMountId, "/readme.txt", []byte("readme"), 1562663291000)
+		server.PutFile("dropbox", "/docs/a.txt", []byte("content"), 1562663291000)
+
+		client := server.Client()
+		out = &bytes.Buffer{}
+		app = &App{
+			Client: client,
+			Config: &Config{},
+			JSON:   true,
+			Out:    out,
+			Mounts: koofrclient.NewMountResolver(client),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	names := func() (names []string) {
+		files := []koofrclient.FileInfo{}
+		Expect(json.Unmarshal(out.Bytes(), &files)).To(Succeed())
+		for _, info := range files {
+			names = append(names, info.Name)
+		}
+		return
+	}
+
+	It("should check the number of arguments", func() {
+		Expect(expectArgs([]string{"a"}, 1, 2)).To(Succeed())
+		Expect(expectArgs([]string{"a", "b"}, 1, 2)).To(Succeed())
+		Expect(expectArgs(nil, 1, 2)).NotTo(Succeed())
+		Expect(expectArgs([]string{"a", "b", "c"}, 1, 2)).NotTo(Succeed())
+
+		Expect(runStat(app, nil)).NotTo(Succeed())
+		Expect(runLs(app, []string{"/", "/"})).NotTo(Succeed())
+		Expect(runCp(app, []string{"/readme.txt"})).NotTo(Succeed())
+		Expect(out.Len()).To(Equal(0))
+	})
+
+	It("should list the primary mount root by default", func() {
+		Expect(runLs(app, nil)).To(Succeed())
+		Expect(names()).To(Equal([]string{"readme.txt"}))
+	})
+
+	It("should resolve mount names and koofr URIs", func() {
+		Expect(runLs(app, []string{"Dropbox:/docs"})).To(Succeed())
+		Expect(names()).To(Equal([]string{"a.txt"}))
+
+		out.Reset()
+		Expect(runStat(app, []string{"koofr://Dropbox/docs/a.txt"})).To(Succeed())
+		info := koofrclient.FileInfo{}
+		Expect(json.Unmarshal(out.Bytes(), &info)).To(Succeed())
+		Expect(info.Name).To(Equal("a.txt"))
+		Expect(info.Size).To(Equal(int64(7)))
+	})
+
+	It("should fail for unknown mounts", func() {
+		Expect(runStat(app, []string{"Missing:/a.txt"})).To(Equal(koofrclient.ErrMountNotFound))
+	})
+
+	It("should write downloads to the output", func() {
+		Expect(runGet(app, []string{"Dropbox:/docs/a.txt", "-"})).To(Succeed())
+		Expect(out.String()).To(Equal("content"))
+	})
+
+	It("should copy between mounts", func() {
+		Expect(runCp(app, []string{"Dropbox:/docs/a.txt", "/a.txt"})).To(Succeed())
+
+		data, ok := server.ReadFile(koofrtest.PrimaryMountId, "/a.txt")
+		Expect(ok).To(BeTrue())
+		Expect(string(data)).To(Equal("content"))
+		_, ok = server.ReadFile("dropbox", "/docs/a.txt")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("should create folders", func() {
+		Expect(runMkdir(app, []string{"Dropbox:/docs/new"})).To(Succeed())
+		Expect(runLs(app, []string{"Dropbox:/docs"})).To(Succeed())
+		Expect(names()).To(ConsistOf("a.txt", "new"))
+		Expect(runMkdir(app, []string{"Dropbox:/"})).NotTo(Succeed())
+	})
+})
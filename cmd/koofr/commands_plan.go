@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+func init() {
+	register(&Command{
+		Name:  "apply",
+		Usage: "apply [-n] <plan.json|->",
+		Help:  "run the operations of a JSON plan",
+		Auth:  true,
+		Run:   runApply,
+	})
+}
+
+func runApply(app *App, args []string) (err error) {
+	flags := flag.NewFlagSet("apply", flag.ExitOnError)
+	dryRun := flags.Bool("n", false, "only print the operations")
+	flags.Parse(args)
+	args = flags.Args()
+
+	if err = expectArgs(args, 1, 1); err != nil {
+		return
+	}
+
+	var r io.Reader = os.Stdin
+
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		r = f
+	}
+
+	plan := &koofrclient.Plan{}
+
+	if err = json.NewDecoder(r).Decode(plan); err != nil {
+		return
+	}
+
+	fmt.Fprint(app.Out, plan)
+
+	if *dryRun {
+		return
+	}
+
+	done, err := plan.Execute(app.Client)
+
+	if err != nil {
+		return fmt.Errorf("%d of %d operations done: %s", done, len(plan.Ops), err)
+	}
+
+	return
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/koofr/go-koofrclient/retention"
+)
+
+func init() {
+	register(&Command{
+		Name:  "prune",
+		Usage: "prune [-n] [rules] <remote folder>",
+		Help:  "delete old backups, keeping the newest per day, week, ...",
+		Auth:  true,
+		Run:   runPrune,
+	})
+}
+
+func runPrune(app *App, args []string) (err error) {
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	options := &retention.Options{}
+	flags.IntVar(&options.Last, "last", 0, "keep the newest N backups")
+	flags.IntVar(&options.Daily, "daily", 0, "keep the newest backup of the last N days with backups")
+	flags.IntVar(&options.Weekly, "weekly", 0, "keep the newest backup of the last N weeks with backups")
+	flags.IntVar(&options.Monthly, "monthly", 0, "keep the newest backup of the last N months with backups")
+	flags.IntVar(&options.Yearly, "yearly", 0, "keep the newest backup of the last N years with backups")
+	flags.StringVar(&options.Pattern, "pattern", "", "only consider names matching this pattern")
+	flags.StringVar(&options.TimeFormat, "format", "", "time layout in backup names, e.g. 2006-01-02 (default: modified time)")
+	flags.BoolVar(&options.All, "all", false, "treat every entry as a backup when neither -pattern nor -format is given")
+	flags.BoolVar(&options.DryRun, "n", false, "only print what would be done")
+	flags.Parse(args)
+	args = flags.Args()
+
+	if err = expectArgs(args, 1, 1); err != nil {
+		return
+	}
+
+	mountId, p, err := app.Mounts.Resolve(args[0])
+
+	if err != nil {
+		return
+	}
+
+	decisions, err := retention.Prune(app.Client, mountId, p, options)
+
+	if err != nil {
+		return
+	}
+
+	if app.JSON {
+		return app.PrintJSON(decisions)
+	}
+
+	for _, d := range decisions {
+		fmt.Fprintln(app.Out, d)
+	}
+
+	return
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/koofr/go-koofrclient/s3gateway"
+)
+
+func init() {
+	register(&Command{
+		Name:  "s3",
+		Usage: "s3 [-addr host:port]",
+		Help:  "serve mounts as buckets over a minimal S3 API",
+		Auth:  true,
+		Run:   runS3,
+	})
+}
+
+func runS3(app *App, args []string) (err error) {
+	flags := flag.NewFlagSet("s3", flag.ExitOnError)
+	addr := flags.String("addr", "127.0.0.1:9000", "listen address")
+	flags.Parse(args)
+
+	if err = expectArgs(flags.Args(), 0, 0); err != nil {
+		return
+	}
+
+	fmt.Fprintf(app.Out, "Serving S3 on http://%s/ (path-style, signatures are not checked)\n", *addr)
+
+	return http.ListenAndServe(*addr, s3gateway.NewGateway(app.Client))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/koofr/go-koofrclient/webdav"
+	xwebdav "golang.org/x/net/webdav"
+)
+
+func init() {
+	register(&Command{
+		Name:  "webdav",
+		Usage: "webdav [-addr host:port] [mount]",
+		Help:  "serve one or all mounts over WebDAV",
+		Auth:  true,
+		Run:   runWebdav,
+	})
+}
+
+func runWebdav(app *App, args []string) (err error) {
+	flags := flag.NewFlagSet("webdav", flag.ExitOnError)
+	addr := flags.String("addr", "127.0.0.1:8080", "listen address")
+	flags.Parse(args)
+	args = flags.Args()
+
+	if err = expectArgs(args, 0, 1); err != nil {
+		return
+	}
+
+	var fs xwebdav.FileSystem = webdav.NewMountsFileSystem(app.Client)
+
+	if len(args) == 1 {
+		mount, err := app.Mounts.Mount(args[0])
+
+		if err != nil {
+			return err
+		}
+
+		fs = webdav.NewFileSystem(app.Client, mount.Id)
+	}
+
+	fmt.Fprintf(app.Out, "Serving WebDAV on http://%s/\n", *addr)
+
+	return http.ListenAndServe(*addr, webdav.NewHandler(fs, ""))
+}
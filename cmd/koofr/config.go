@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const DefaultApiBase = "https://app.koofr.net"
+
+type Config struct {
+	ApiBase string `json:"apiBase"`
+	Token   string `json:"token"`
+	UserID  string `json:"userId,omitempty"`
+}
+
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+
+	if err != nil {
+		dir = "."
+	}
+
+	return filepath.Join(dir, "koofr", "config.json")
+}
+
+// LoadConfig reads the config file. A missing file is not an error. The
+// KOOFR_APIBASE and KOOFR_TOKEN environment variables take precedence over
+// the file.
+func LoadConfig(path string) (config *Config, err error) {
+	config = &Config{}
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		if err = json.Unmarshal(data, config); err != nil {
+			return nil, err
+		}
+	}
+
+	if apiBase := os.Getenv("KOOFR_APIBASE"); apiBase != "" {
+		config.ApiBase = apiBase
+	}
+
+	if token := os.Getenv("KOOFR_TOKEN"); token != "" {
+		config.Token = token
+	}
+
+	if config.ApiBase == "" {
+		config.ApiBase = DefaultApiBase
+	}
+
+	return config, nil
+}
+
+func (c *Config) Save(path string) (err error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+
+	if err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+
+	return ioutil.WriteFile(path, append(data, '\n'), 0600)
+}
@@ -0,0 +1,115 @@
+// Command koofr is a command-line client for the Koofr API.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+type Command struct {
+	Name  string
+	Usage string
+	Help  string
+	Auth  bool
+	Run   func(app *App, args []string) error
+}
+
+type App struct {
+	Client     *koofrclient.KoofrClient
+	Config     *Config
+	ConfigPath string
+	JSON       bool
+	Out        io.Writer
+	Mounts     *koofrclient.MountResolver
+}
+
+var commands = map[string]*Command{}
+
+func register(cmd *Command) {
+	commands[cmd.Name] = cmd
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+
+	fmt.Fprintf(out, "Usage: koofr [flags] <command> [args]\n\nCommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-40s %s\n", commands[name].Usage, commands[name].Help)
+	}
+
+	fmt.Fprintf(out, "\nRemote paths are given as mountName:/path, koofr://mountName/path or /path\nfor the primary mount.\n\nFlags:\n")
+
+	flag.PrintDefaults()
+}
+
+func main() {
+	configPath := flag.String("config", DefaultConfigPath(), "config file path")
+	apiBase := flag.String("api", "", "API base URL (overrides config)")
+	insecure := flag.Bool("insecure", false, "disable TLS certificate verification")
+	jsonOutput := flag.Bool("json", false, "print JSON output")
+
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+
+	if !ok {
+		fmt.Fprintf(os.Stderr, "koofr: unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	config, err := LoadConfig(*configPath)
+
+	if err != nil {
+		fatal(err)
+	}
+
+	if *apiBase != "" {
+		config.ApiBase = *apiBase
+	}
+
+	client := koofrclient.NewKoofrClient(config.ApiBase, *insecure)
+
+	if config.Token != "" {
+		client.SetToken(config.Token)
+		client.SetUserID(config.UserID)
+	} else if cmd.Auth {
+		fatal(fmt.Errorf("not logged in, run koofr login first"))
+	}
+
+	app := &App{
+		Client:     client,
+		Config:     config,
+		ConfigPath: *configPath,
+		JSON:       *jsonOutput,
+		Out:        os.Stdout,
+		Mounts:     koofrclient.NewMountResolver(client),
+	}
+
+	if err = cmd.Run(app, flag.Args()[1:]); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "koofr: %s\n", err)
+	os.Exit(1)
+}
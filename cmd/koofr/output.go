@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+func (app *App) PrintJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(app.Out, "%s\n", data)
+
+	return err
+}
+
+// PrintTable prints v as JSON in JSON mode and rows as aligned columns
+// otherwise.
+func (app *App) PrintTable(v interface{}, header []interface{}, rows [][]interface{}) error {
+	if app.JSON {
+		return app.PrintJSON(v)
+	}
+
+	w := tabwriter.NewWriter(app.Out, 0, 4, 2, ' ', 0)
+
+	for _, row := range append([][]interface{}{header}, rows...) {
+		for i, col := range row {
+			if i > 0 {
+				fmt.Fprint(w, "\t")
+			}
+			fmt.Fprint(w, col)
+		}
+		fmt.Fprint(w, "\n")
+	}
+
+	return w.Flush()
+}
+
+func formatTime(ms int64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).Local().Format("2006-01-02 15:04:05")
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,140 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const DefaultSalt = "go-koofrclient/crypt"
+
+var ErrInvalidName = fmt.Errorf("Invalid encrypted name")
+
+var nameEncoding = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// Cipher holds the keys derived from a user passphrase. It is safe for
+// concurrent use.
+type Cipher struct {
+	dataAEAD cipher.AEAD
+	nameAEAD cipher.AEAD
+	nameKey  []byte
+}
+
+func NewCipher(passphrase string, salt string) (c *Cipher, err error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("Passphrase must not be empty")
+	}
+
+	if salt == "" {
+		salt = DefaultSalt
+	}
+
+	keys, err := scrypt.Key([]byte(passphrase), []byte(salt), 1<<15, 8, 1, 3*32)
+
+	if err != nil {
+		return
+	}
+
+	dataAEAD, err := newAEAD(keys[0:32])
+
+	if err != nil {
+		return
+	}
+
+	nameAEAD, err := newAEAD(keys[32:64])
+
+	if err != nil {
+		return
+	}
+
+	c = &Cipher{
+		dataAEAD: dataAEAD,
+		nameAEAD: nameAEAD,
+		nameKey:  keys[64:96],
+	}
+
+	return
+}
+
+func newAEAD(key []byte) (aead cipher.AEAD, err error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// EncryptName encrypts a single path segment. The nonce is derived from the
+// name itself so equal names always encrypt to equal ciphertexts, which keeps
+// lookups by path possible.
+func (c *Cipher) EncryptName(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, c.nameKey)
+	mac.Write([]byte(name))
+	nonce := mac.Sum(nil)[:c.nameAEAD.NonceSize()]
+
+	sealed := c.nameAEAD.Seal(nonce, nonce, []byte(name), nil)
+
+	return strings.ToLower(nameEncoding.EncodeToString(sealed))
+}
+
+func (c *Cipher) DecryptName(encrypted string) (name string, err error) {
+	if encrypted == "" {
+		return "", nil
+	}
+
+	sealed, err := nameEncoding.DecodeString(strings.ToUpper(encrypted))
+
+	if err != nil {
+		return "", ErrInvalidName
+	}
+
+	nonceSize := c.nameAEAD.NonceSize()
+
+	if len(sealed) < nonceSize+c.nameAEAD.Overhead() {
+		return "", ErrInvalidName
+	}
+
+	plain, err := c.nameAEAD.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+
+	if err != nil {
+		return "", ErrInvalidName
+	}
+
+	return string(plain), nil
+}
+
+// EncryptPath encrypts every segment of a slash separated path.
+func (c *Cipher) EncryptPath(p string) string {
+	parts := strings.Split(path.Clean("/"+p), "/")
+
+	for i, part := range parts {
+		parts[i] = c.EncryptName(part)
+	}
+
+	return path.Join("/", strings.Join(parts, "/"))
+}
+
+func (c *Cipher) DecryptPath(p string) (decrypted string, err error) {
+	parts := strings.Split(path.Clean("/"+p), "/")
+
+	for i, part := range parts {
+		if parts[i], err = c.DecryptName(part); err != nil {
+			return
+		}
+	}
+
+	return path.Join("/", strings.Join(parts, "/")), nil
+}
@@ -0,0 +1,42 @@
+package crypt_test
+
+import (
+	"github.com/koofr/go-koofrclient/crypt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cipher", func() {
+	It("should not accept an empty passphrase", func() {
+		_, err := crypt.NewCipher("", "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should encrypt names deterministically", func() {
+		encrypted := cipher.EncryptName("file.txt")
+		Expect(encrypted).NotTo(Equal("file.txt"))
+		Expect(cipher.EncryptName("file.txt")).To(Equal(encrypted))
+		Expect(cipher.EncryptName("other.txt")).NotTo(Equal(encrypted))
+		name, err := cipher.DecryptName(encrypted)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(Equal("file.txt"))
+	})
+
+	It("should reject names not encrypted with the same key", func() {
+		other, err := crypt.NewCipher("other", "")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = cipher.DecryptName(other.EncryptName("file.txt"))
+		Expect(err).To(Equal(crypt.ErrInvalidName))
+		_, err = cipher.DecryptName("file.txt")
+		Expect(err).To(Equal(crypt.ErrInvalidName))
+	})
+
+	It("should encrypt and decrypt paths", func() {
+		encrypted := cipher.EncryptPath("/dir/sub/file.txt")
+		Expect(encrypted).To(HavePrefix("/" + cipher.EncryptName("dir") + "/"))
+		decrypted, err := cipher.DecryptPath(encrypted)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decrypted).To(Equal("/dir/sub/file.txt"))
+		Expect(cipher.EncryptPath("/")).To(Equal("/"))
+	})
+})
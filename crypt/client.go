@@ -0,0 +1,269 @@
+// Package crypt wraps the Koofr files API with client-side encryption of file
+// contents and, optionally, file names.
+package crypt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+var ErrUnknownSize = fmt.Errorf("Content length is required to check free space")
+
+type Client struct {
+	client       *koofrclient.KoofrClient
+	cipher       *Cipher
+	encryptNames bool
+}
+
+// NewClient returns a Client that encrypts everything it uploads through
+// client. If encryptNames is set, every path segment below the mount root is
+// encrypted too and entries with names that fail to decrypt are left out of
+// listings.
+func NewClient(client *koofrclient.KoofrClient, cipher *Cipher, encryptNames bool) *Client {
+	return &Client{
+		client:       client,
+		cipher:       cipher,
+		encryptNames: encryptNames,
+	}
+}
+
+func (c *Client) encryptPath(p string) string {
+	if !c.encryptNames {
+		return p
+	}
+	return c.cipher.EncryptPath(p)
+}
+
+func (c *Client) encryptName(name string) string {
+	if !c.encryptNames {
+		return name
+	}
+	return c.cipher.EncryptName(name)
+}
+
+func (c *Client) decryptName(name string) (string, error) {
+	if !c.encryptNames {
+		return name, nil
+	}
+	return c.cipher.DecryptName(name)
+}
+
+func (c *Client) decryptInfo(info *koofrclient.FileInfo) (err error) {
+	if info.Type == "file" {
+		if info.Size, err = DecryptedSize(info.Size); err != nil {
+			return
+		}
+	}
+
+	info.Name, err = c.decryptName(info.Name)
+
+	return
+}
+
+func (c *Client) FilesInfo(mountId string, p string) (info koofrclient.FileInfo, err error) {
+	info, err = c.client.FilesInfo(mountId, c.encryptPath(p))
+
+	if err != nil {
+		return
+	}
+
+	if path.Clean("/"+p) == "/" {
+		return
+	}
+
+	err = c.decryptInfo(&info)
+
+	return
+}
+
+func (c *Client) FilesList(mountId string, basePath string) (files []koofrclient.FileInfo, err error) {
+	encrypted, err := c.client.FilesList(mountId, c.encryptPath(basePath))
+
+	if err != nil {
+		return
+	}
+
+	files = make([]koofrclient.FileInfo, 0, len(encrypted))
+
+	for _, info := range encrypted {
+		if c.decryptInfo(&info) != nil {
+			continue
+		}
+
+		info.Path = path.Join(basePath, info.Name)
+
+		files = append(files, info)
+	}
+
+	return
+}
+
+func (c *Client) FilesNewFolder(mountId string, p string, name string) (err error) {
+	return c.client.FilesNewFolder(mountId, c.encryptPath(p), c.encryptName(name))
+}
+
+func (c *Client) FilesDelete(mountId string, p string) (err error) {
+	return c.client.FilesDelete(mountId, c.encryptPath(p))
+}
+
+func (c *Client) FilesPut(mountId string, p string, name string, reader io.Reader) (newName string, err error) {
+	info, err := c.FilesPutWithOptions(mountId, p, name, reader, nil)
+
+	if err != nil {
+		return
+	}
+
+	return info.Name, nil
+}
+
+// FilesPutWithOptions encrypts reader and uploads it. OverwriteIfSize and
+// ContentLength are given as plaintext sizes; OverwriteIfHash refers to the
+// stored (encrypted) content. With name encryption enabled the server must
+// not rename the file, so NoRename is always set. The size of the encrypted
+// stream cannot be detected, so CheckSpace fails with ErrUnknownSize unless
+// ContentLength is set.
+func (c *Client) FilesPutWithOptions(mountId string, p string, name string, reader io.Reader, putOptions *koofrclient.PutOptions) (fileInfo *koofrclient.FileInfo, err error) {
+	if putOptions != nil && putOptions.CheckSpace && putOptions.ContentLength == nil {
+		return nil, ErrUnknownSize
+	}
+
+	encrypter, err := c.cipher.NewEncrypter(reader)
+
+	if err != nil {
+		return
+	}
+
+	options := koofrclient.PutOptions{}
+
+	if putOptions != nil {
+		options = *putOptions
+	}
+
+	if options.OverwriteIfSize != nil {
+		size := EncryptedSize(*options.OverwriteIfSize)
+		options.OverwriteIfSize = &size
+	}
+
+	if options.ContentLength != nil {
+		size := EncryptedSize(*options.ContentLength)
+		options.ContentLength = &size
+	}
+
+	if c.encryptNames {
+		options.NoRename = true
+	}
+
+	fileInfo, err = c.client.FilesPutWithOptions(mountId, c.encryptPath(p), c.encryptName(name), encrypter, &options)
+
+	if err != nil {
+		return
+	}
+
+	if err = c.decryptInfo(fileInfo); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func (c *Client) FilesGet(mountId string, p string) (reader io.ReadCloser, err error) {
+	body, err := c.client.FilesGet(mountId, c.encryptPath(p))
+
+	if err != nil {
+		return
+	}
+
+	decrypter, err := c.cipher.NewDecrypter(body)
+
+	if err != nil {
+		body.Close()
+		return
+	}
+
+	return readCloser{decrypter, body}, nil
+}
+
+// FilesGetRange returns the plaintext bytes span.Start to span.End
+// (inclusive, -1 meaning the end of file). Only the encrypted chunks
+// covering the span are downloaded.
+func (c *Client) FilesGetRange(mountId string, p string, span *koofrclient.FileSpan) (reader io.ReadCloser, err error) {
+	if span == nil {
+		return c.FilesGet(mountId, p)
+	}
+
+	encPath := c.encryptPath(p)
+
+	info, err := c.client.FilesInfo(mountId, encPath)
+
+	if err != nil {
+		return
+	}
+
+	size, err := DecryptedSize(info.Size)
+
+	if err != nil {
+		return
+	}
+
+	start, end := span.Start, span.End
+
+	if end == -1 || end >= size {
+		end = size - 1
+	}
+
+	if start > end {
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	header, err := c.client.FilesGetRange(mountId, encPath, &koofrclient.FileSpan{Start: 0, End: int64(HeaderSize) - 1})
+
+	if err != nil {
+		return
+	}
+
+	nonce, err := readHeader(header)
+
+	header.Close()
+
+	if err != nil {
+		return
+	}
+
+	firstChunk := start / ChunkSize
+	lastChunk := end / ChunkSize
+	fileLastChunk := chunkCount(size) - 1
+
+	encSpan := &koofrclient.FileSpan{
+		Start: int64(HeaderSize) + firstChunk*encryptedChunkSize,
+		End:   int64(HeaderSize) + (lastChunk+1)*encryptedChunkSize - 1,
+	}
+
+	if lastChunk == fileLastChunk {
+		encSpan.End = -1
+	}
+
+	body, err := c.client.FilesGetRange(mountId, encPath, encSpan)
+
+	if err != nil {
+		return
+	}
+
+	decrypter := c.cipher.newChunkDecrypter(body, nonce, firstChunk, fileLastChunk)
+
+	if _, err = io.CopyN(ioutil.Discard, decrypter, start-firstChunk*ChunkSize); err != nil {
+		body.Close()
+		return nil, err
+	}
+
+	return readCloser{io.LimitReader(decrypter, end-start+1), body}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,127 @@
+package crypt_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+	"github.com/koofr/go-koofrclient/crypt"
+	"github.com/koofr/go-koofrclient/koofrtest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var server *koofrtest.Server
+	var client *crypt.Client
+	var content []byte
+
+	const mountId = koofrtest.PrimaryMountId
+
+	BeforeEach(func() {
+		server = koofrtest.NewServer()
+		client = crypt.NewClient(server.Client(), cipher, true)
+
+		content = make([]byte, 3*crypt.ChunkSize+100)
+		rand.New(rand.NewSource(1)).Read(content)
+
+		Expect(client.FilesNewFolder(mountId, "/", "dir")).To(Succeed())
+		_, err := client.FilesPut(mountId, "/dir", "file.bin", bytes.NewReader(content))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	read := func(reader io.ReadCloser, err error) []byte {
+		Expect(err).NotTo(HaveOccurred())
+		defer reader.Close()
+		data, err := ioutil.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		return data
+	}
+
+	It("should store encrypted names and content", func() {
+		stored, ok := server.ReadFile(mountId, cipher.EncryptPath("/dir/file.bin"))
+		Expect(ok).To(BeTrue())
+		Expect(int64(len(stored))).To(Equal(crypt.EncryptedSize(int64(len(content)))))
+		Expect(bytes.Contains(stored, content[:64])).To(BeFalse())
+
+		_, ok = server.ReadFile(mountId, "/dir/file.bin")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should put and get files", func() {
+		Expect(read(client.FilesGet(mountId, "/dir/file.bin"))).To(Equal(content))
+
+		info, err := client.FilesInfo(mountId, "/dir/file.bin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Name).To(Equal("file.bin"))
+		Expect(info.Size).To(Equal(int64(len(content))))
+	})
+
+	It("should require the content length to check free space", func() {
+		_, err := client.FilesPutWithOptions(mountId, "/dir", "new.bin", bytes.NewReader(content), &koofrclient.PutOptions{
+			CheckSpace: true,
+		})
+		Expect(err).To(Equal(crypt.ErrUnknownSize))
+
+		size := int64(len(content))
+		_, err = client.FilesPutWithOptions(mountId, "/dir", "new.bin", bytes.NewReader(content), &koofrclient.PutOptions{
+			ContentLength: &size,
+			CheckSpace:    true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should get ranges across chunk boundaries", func() {
+		spans := []koofrclient.FileSpan{
+			{Start: crypt.ChunkSize - 10, End: crypt.ChunkSize + 9},
+			{Start: 0, End: 2*crypt.ChunkSize + 5},
+			{Start: 3*crypt.ChunkSize + 50, End: -1},
+			{Start: 5, End: 5},
+		}
+
+		for _, span := range spans {
+			end := span.End
+			if end == -1 {
+				end = int64(len(content)) - 1
+			}
+
+			data := read(client.FilesGetRange(mountId, "/dir/file.bin", &koofrclient.FileSpan{Start: span.Start, End: span.End}))
+			Expect(data).To(Equal(content[span.Start : end+1]))
+		}
+	})
+
+	It("should list only names encrypted with the same key", func() {
+		other, err := crypt.NewCipher("other", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		dir := cipher.EncryptPath("/dir")
+		server.PutFile(mountId, dir+"/plain.txt", []byte("plain"), 1000)
+		server.PutFile(mountId, dir+"/"+other.EncryptName("other.txt"), []byte("other"), 1000)
+
+		files, err := client.FilesList(mountId, "/dir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Name).To(Equal("file.bin"))
+		Expect(files[0].Path).To(Equal("/dir/file.bin"))
+		Expect(files[0].Size).To(Equal(int64(len(content))))
+	})
+
+	It("should keep names without name encryption", func() {
+		plainNames := crypt.NewClient(server.Client(), cipher, false)
+
+		_, err := plainNames.FilesPut(mountId, "/", "visible.txt", bytes.NewReader([]byte("content")))
+		Expect(err).NotTo(HaveOccurred())
+
+		stored, ok := server.ReadFile(mountId, "/visible.txt")
+		Expect(ok).To(BeTrue())
+		Expect(string(stored)).NotTo(Equal("content"))
+
+		Expect(string(read(plainNames.FilesGet(mountId, "/visible.txt")))).To(Equal("content"))
+	})
+})
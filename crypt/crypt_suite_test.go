@@ -0,0 +1,25 @@
+package crypt_test
+
+import (
+	"testing"
+
+	"github.com/koofr/go-koofrclient/crypt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var cipher *crypt.Cipher
+
+func TestCrypt(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	var err error
+
+	cipher, err = crypt.NewCipher("passphrase", "")
+
+	if err != nil {
+		t.Fatal("Creating cipher failed")
+	}
+
+	RunSpecs(t, "Crypt Suite")
+}
@@ -0,0 +1,287 @@
+package crypt
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Encrypted files start with a header (magic and a random base nonce)
+// followed by chunks of at most ChunkSize plaintext bytes, each sealed with
+// AES-GCM. The chunk nonce is the base nonce combined with the chunk index and
+// the last chunk is authenticated as such, so reordering, dropping or
+// truncating chunks is detected.
+const (
+	ChunkSize  = 64 * 1024
+	HeaderSize = len(fileMagic) + nonceSize
+
+	fileMagic = "KCRYPT\x00\x01"
+	nonceSize = 12
+	tagSize   = 16
+
+	encryptedChunkSize = ChunkSize + tagSize
+)
+
+var ErrInvalidHeader = fmt.Errorf("Invalid encrypted file header")
+var ErrAuthentication = fmt.Errorf("Encrypted data failed authentication")
+var ErrTruncated = fmt.Errorf("Encrypted data is truncated")
+
+var (
+	aadIntermediate = []byte{0}
+	aadFinal        = []byte{1}
+)
+
+// EncryptedSize returns the size of the encrypted file for a plaintext of
+// the given size.
+func EncryptedSize(size int64) int64 {
+	return int64(HeaderSize) + size + chunkCount(size)*tagSize
+}
+
+// DecryptedSize returns the plaintext size of an encrypted file of the
+// given size.
+func DecryptedSize(size int64) (int64, error) {
+	rest := size - int64(HeaderSize)
+
+	if rest < tagSize {
+		return 0, ErrTruncated
+	}
+
+	chunks := (rest + encryptedChunkSize - 1) / encryptedChunkSize
+
+	if rest-(chunks-1)*encryptedChunkSize < tagSize {
+		return 0, ErrTruncated
+	}
+
+	return rest - chunks*tagSize, nil
+}
+
+func chunkCount(size int64) int64 {
+	if size == 0 {
+		return 1
+	}
+	return (size + ChunkSize - 1) / ChunkSize
+}
+
+func chunkNonce(base []byte, index int64) []byte {
+	nonce := make([]byte, nonceSize)
+	copy(nonce, base)
+	counter := binary.BigEndian.Uint64(nonce[nonceSize-8:]) ^ uint64(index)
+	binary.BigEndian.PutUint64(nonce[nonceSize-8:], counter)
+	return nonce
+}
+
+func readHeader(r io.Reader) (nonce []byte, err error) {
+	header := make([]byte, HeaderSize)
+
+	if _, err = io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrInvalidHeader
+		}
+		return
+	}
+
+	if string(header[:len(fileMagic)]) != fileMagic {
+		return nil, ErrInvalidHeader
+	}
+
+	return header[len(fileMagic):], nil
+}
+
+type encryptReader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	nonce   []byte
+	index   int64
+	plain   []byte
+	pending []byte
+	done    bool
+}
+
+// NewEncrypter returns a reader producing the encrypted form of r.
+func (c *Cipher) NewEncrypter(r io.Reader) (reader io.Reader, err error) {
+	nonce := make([]byte, nonceSize)
+
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return
+	}
+
+	header := make([]byte, 0, HeaderSize)
+	header = append(header, fileMagic...)
+	header = append(header, nonce...)
+
+	reader = &encryptReader{
+		src:     bufio.NewReaderSize(r, ChunkSize),
+		aead:    c.dataAEAD,
+		nonce:   nonce,
+		plain:   make([]byte, ChunkSize),
+		pending: header,
+	}
+
+	return
+}
+
+func (e *encryptReader) Read(p []byte) (n int, err error) {
+	for len(e.pending) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		if err = e.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(p, e.pending)
+	e.pending = e.pending[n:]
+
+	return n, nil
+}
+
+func (e *encryptReader) nextChunk() (err error) {
+	n, err := io.ReadFull(e.src, e.plain)
+
+	final := false
+
+	switch err {
+	case nil:
+		if _, err = e.src.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	case io.EOF, io.ErrUnexpectedEOF:
+		final = true
+	default:
+		return err
+	}
+
+	aad := aadIntermediate
+	if final {
+		aad = aadFinal
+	}
+
+	e.pending = e.aead.Seal(e.pending[:0], chunkNonce(e.nonce, e.index), e.plain[:n], aad)
+	e.index++
+	e.done = final
+
+	return nil
+}
+
+type decryptReader struct {
+	src        io.Reader
+	aead       cipher.AEAD
+	nonce      []byte
+	index      int64
+	lastIndex  int64
+	sealed     []byte
+	pending    []byte
+	done       bool
+	err        error
+	peekReader *bufio.Reader
+}
+
+// NewDecrypter reads the header from r and returns a reader producing the
+// decrypted content.
+func (c *Cipher) NewDecrypter(r io.Reader) (reader io.Reader, err error) {
+	nonce, err := readHeader(r)
+
+	if err != nil {
+		return
+	}
+
+	br := bufio.NewReaderSize(r, encryptedChunkSize)
+
+	reader = &decryptReader{
+		src:        br,
+		aead:       c.dataAEAD,
+		nonce:      nonce,
+		lastIndex:  -1,
+		sealed:     make([]byte, encryptedChunkSize),
+		peekReader: br,
+	}
+
+	return
+}
+
+// newChunkDecrypter decrypts a stream starting at chunk startIndex of a file
+// with lastIndex+1 chunks. The stream may end at any chunk boundary.
+func (c *Cipher) newChunkDecrypter(r io.Reader, nonce []byte, startIndex int64, lastIndex int64) io.Reader {
+	return &decryptReader{
+		src:       r,
+		aead:      c.dataAEAD,
+		nonce:     nonce,
+		index:     startIndex,
+		lastIndex: lastIndex,
+		sealed:    make([]byte, encryptedChunkSize),
+	}
+}
+
+func (d *decryptReader) Read(p []byte) (n int, err error) {
+	for len(d.pending) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.nextChunk()
+	}
+
+	n = copy(p, d.pending)
+	d.pending = d.pending[n:]
+
+	return n, nil
+}
+
+func (d *decryptReader) nextChunk() (err error) {
+	n, err := io.ReadFull(d.src, d.sealed)
+
+	final := false
+
+	switch err {
+	case nil:
+		if d.lastIndex >= 0 {
+			final = d.index == d.lastIndex
+		} else if _, err = d.peekReader.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	case io.EOF:
+		if d.lastIndex >= 0 && d.index <= d.lastIndex {
+			// range reads may stop at any chunk boundary
+			d.done = true
+			return nil
+		}
+		return ErrTruncated
+	case io.ErrUnexpectedEOF:
+		if d.lastIndex >= 0 && d.index != d.lastIndex {
+			return ErrTruncated
+		}
+		final = true
+	default:
+		return err
+	}
+
+	aad := aadIntermediate
+	if final {
+		aad = aadFinal
+	}
+
+	if n < tagSize {
+		return ErrTruncated
+	}
+
+	d.pending, err = d.aead.Open(d.sealed[:0], chunkNonce(d.nonce, d.index), d.sealed[:n], aad)
+
+	if err != nil {
+		return ErrAuthentication
+	}
+
+	d.index++
+	d.done = final
+
+	return nil
+}
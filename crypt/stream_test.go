@@ -0,0 +1,68 @@
+package crypt_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+
+	"github.com/koofr/go-koofrclient/crypt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream", func() {
+	encrypt := func(content []byte) []byte {
+		encrypter, err := cipher.NewEncrypter(bytes.NewReader(content))
+		Expect(err).NotTo(HaveOccurred())
+		encrypted, err := ioutil.ReadAll(encrypter)
+		Expect(err).NotTo(HaveOccurred())
+		return encrypted
+	}
+
+	decrypt := func(encrypted []byte) ([]byte, error) {
+		decrypter, err := cipher.NewDecrypter(bytes.NewReader(encrypted))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(decrypter)
+	}
+
+	for _, size := range []int{0, 1, crypt.ChunkSize - 1, crypt.ChunkSize, crypt.ChunkSize + 1, 3*crypt.ChunkSize + 17} {
+		size := size
+
+		It("should encrypt and decrypt content", func() {
+			content := make([]byte, size)
+			rand.Read(content)
+			encrypted := encrypt(content)
+			Expect(int64(len(encrypted))).To(Equal(crypt.EncryptedSize(int64(size))))
+			decryptedSize, err := crypt.DecryptedSize(int64(len(encrypted)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decryptedSize).To(Equal(int64(size)))
+			decrypted, err := decrypt(encrypted)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decrypted).To(Equal(content))
+		})
+	}
+
+	It("should use a fresh nonce for every file", func() {
+		Expect(encrypt([]byte("content"))).NotTo(Equal(encrypt([]byte("content"))))
+	})
+
+	It("should detect modified content", func() {
+		encrypted := encrypt([]byte("content"))
+		encrypted[len(encrypted)-1] ^= 1
+		_, err := decrypt(encrypted)
+		Expect(err).To(Equal(crypt.ErrAuthentication))
+	})
+
+	It("should detect truncation at a chunk boundary", func() {
+		encrypted := encrypt(make([]byte, 2*crypt.ChunkSize))
+		_, err := decrypt(encrypted[:crypt.HeaderSize+crypt.ChunkSize+16])
+		Expect(err).To(Equal(crypt.ErrAuthentication))
+	})
+
+	It("should reject an invalid header", func() {
+		_, err := decrypt([]byte("content"))
+		Expect(err).To(Equal(crypt.ErrInvalidHeader))
+	})
+})
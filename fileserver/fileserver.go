@@ -0,0 +1,196 @@
+// Package fileserver serves files from a Koofr mount over HTTP, with support
+// for HEAD, ranges and conditional requests, and optional folder listings.
+package fileserver
+
+import (
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/koofr/go-httpclient"
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+type Options struct {
+	// Root is the folder served at "/". Defaults to the mount root.
+	Root string
+	// Listings enables HTML listings of folders. Without it, folders are
+	// not found.
+	Listings bool
+}
+
+// Handler serves the files of a mount. Request paths are relative to
+// Options.Root; use http.StripPrefix to mount the handler below a prefix.
+//
+// HEAD requests are answered from FilesInfo alone. GET requests fetch only
+// the requested range of the file. Content-Type, ETag (the file hash) and
+// Last-Modified are set from the file info, and If-Range, If-None-Match and
+// If-Modified-Since are honored.
+type Handler struct {
+	client  *koofrclient.KoofrClient
+	mountId string
+	options Options
+}
+
+func NewHandler(client *koofrclient.KoofrClient, mountId string, options *Options) *Handler {
+	h := &Handler{
+		client:  client,
+		mountId: mountId,
+	}
+
+	if options != nil {
+		h.options = *options
+	}
+
+	h.options.Root = path.Clean("/" + h.options.Root)
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	client := h.client.WithContext(r.Context())
+
+	p := path.Join(h.options.Root, path.Clean("/"+r.URL.Path))
+
+	info, err := client.FilesInfo(h.mountId, p)
+
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	if info.Type == "dir" {
+		if !h.options.Listings {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			redirect(w, r, path.Base(r.URL.Path)+"/")
+			return
+		}
+
+		h.serveListing(w, r, client, p)
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/") {
+		redirect(w, r, "../"+path.Base(r.URL.Path))
+		return
+	}
+
+	contentType := info.ContentType
+
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(info.Name))
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+
+	if info.Hash != "" {
+		w.Header().Set("ETag", `"`+info.Hash+`"`)
+	}
+
+	reader := client.NewFileReader(h.mountId, p, info.Size)
+	reader.SetRangeHeader(r.Header.Get("Range"))
+	defer reader.Close()
+
+	http.ServeContent(w, r, info.Name, time.Unix(0, info.Modified*int64(time.Millisecond)), reader)
+}
+
+// redirect sends a redirect relative to the request path. Unlike
+// http.Redirect, it does not resolve target against r.URL.Path, which is
+// wrong below http.StripPrefix.
+func redirect(w http.ResponseWriter, r *http.Request, target string) {
+	target = (&url.URL{Path: target, RawQuery: r.URL.RawQuery}).String()
+
+	w.Header().Set("Location", target)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
+
+func serveError(w http.ResponseWriter, err error) {
+	switch {
+	case koofrclient.IsNotFound(err):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case httpclient.IsInvalidStatusCode(err, http.StatusForbidden):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	default:
+		http.Error(w, "Bad gateway", http.StatusBadGateway)
+	}
+}
+
+type listingEntry struct {
+	Name     string
+	URL      string
+	Size     int64
+	Dir      bool
+	Modified time.Time
+}
+
+var listingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Path}}</title></head>
+<body>
+<h1>{{.Path}}</h1>
+<table>
+{{if ne .Path "/"}}<tr><td><a href="../">../</a></td><td></td><td></td></tr>
+{{end}}{{range .Entries}}<tr><td><a href="{{.URL}}">{{.Name}}{{if .Dir}}/{{end}}</a></td><td>{{if not .Dir}}{{.Size}}{{end}}</td><td>{{.Modified.UTC.Format "2006-01-02 15:04:05"}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func (h *Handler) serveListing(w http.ResponseWriter, r *http.Request, client *koofrclient.KoofrClient, p string) {
+	files, err := client.FilesList(h.mountId, p)
+
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	entries := make([]listingEntry, len(files))
+
+	for i, file := range files {
+		entry := listingEntry{
+			Name:     file.Name,
+			URL:      (&url.URL{Path: file.Name}).String(),
+			Size:     file.Size,
+			Dir:      file.Type == "dir",
+			Modified: time.Unix(0, file.Modified*int64(time.Millisecond)),
+		}
+
+		if entry.Dir {
+			entry.URL += "/"
+		}
+
+		entries[i] = entry
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if r.Method == "HEAD" {
+		return
+	}
+
+	listingTemplate.Execute(w, struct {
+		Path    string
+		Entries []listingEntry
+	}{
+		Path:    path.Clean("/" + r.URL.Path),
+		Entries: entries,
+	})
+}
@@ -0,0 +1,13 @@
+package fileserver_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFileserver(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fileserver Suite")
+}
@@ -0,0 +1,133 @@
+package fileserver_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+	"github.com/koofr/go-koofrclient/fileserver"
+	"github.com/koofr/go-koofrclient/koofrtest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler", func() {
+	var server *koofrtest.Server
+	var options *fileserver.Options
+	var web *httptest.Server
+	var ranges []string
+
+	BeforeEach(func() {
+		server = koofrtest.NewServer()
+		server.PutFile(koofrtest.PrimaryMountId, "/media/file.txt", []byte("content"), 1562663291000)
+		server.PutFile(koofrtest.PrimaryMountId, "/media/a b/c.txt", []byte("c"), 1562663291000)
+		options = &fileserver.Options{Root: "/media"}
+	})
+
+	JustBeforeEach(func() {
+		ranges = nil
+		client := server.Client()
+		client.AddRequestHook(func(event *koofrclient.RequestEvent) {
+			if strings.HasSuffix(event.Path, "/files/get") {
+				ranges = append(ranges, event.Headers.Get("Range"))
+			}
+		})
+		web = httptest.NewServer(http.StripPrefix("/files", fileserver.NewHandler(client, koofrtest.PrimaryMountId, options)))
+	})
+
+	AfterEach(func() {
+		web.Close()
+		server.Close()
+	})
+
+	do := func(method string, path string, headers map[string]string) (*http.Response, string) {
+		req, err := http.NewRequest(method, web.URL+path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		res, err := http.DefaultTransport.RoundTrip(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+		data, err := ioutil.ReadAll(res.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return res, string(data)
+	}
+
+	It("should serve files with metadata headers", func() {
+		res, body := do("GET", "/files/file.txt", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).To(Equal("content"))
+		Expect(res.Header.Get("Content-Type")).To(HavePrefix("text/plain"))
+		Expect(res.Header.Get("ETag")).To(Equal(`"9a0364b9e99bb480dd25e1f0284c8555"`))
+		Expect(res.Header.Get("Last-Modified")).To(Equal("Tue, 09 Jul 2019 09:08:11 GMT"))
+		Expect(res.Header.Get("Accept-Ranges")).To(Equal("bytes"))
+	})
+
+	It("should answer HEAD without the body", func() {
+		res, body := do("HEAD", "/files/file.txt", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).To(BeEmpty())
+		Expect(res.Header.Get("Content-Length")).To(Equal("7"))
+	})
+
+	It("should serve ranges", func() {
+		res, body := do("GET", "/files/file.txt", map[string]string{"Range": "bytes=2-3"})
+		Expect(res.StatusCode).To(Equal(http.StatusPartialContent))
+		Expect(body).To(Equal("nt"))
+		Expect(res.Header.Get("Content-Range")).To(Equal("bytes 2-3/7"))
+		Expect(ranges).To(Equal([]string{"bytes=2-3"}))
+	})
+
+	It("should ignore the range if If-Range does not match", func() {
+		res, body := do("GET", "/files/file.txt", map[string]string{"Range": "bytes=2-3", "If-Range": `"other"`})
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).To(Equal("content"))
+	})
+
+	It("should answer conditional requests", func() {
+		res, _ := do("GET", "/files/file.txt", map[string]string{"If-None-Match": `"9a0364b9e99bb480dd25e1f0284c8555"`})
+		Expect(res.StatusCode).To(Equal(http.StatusNotModified))
+		res, _ = do("GET", "/files/file.txt", map[string]string{"If-Modified-Since": "Tue, 09 Jul 2019 09:08:11 GMT"})
+		Expect(res.StatusCode).To(Equal(http.StatusNotModified))
+	})
+
+	It("should not serve files outside of the root", func() {
+		res, _ := do("GET", "/files/../media/file.txt", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		res, _ = do("GET", "/files/missing.txt", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("should not list folders by default", func() {
+		res, _ := do("GET", "/files/", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("should reject other methods", func() {
+		res, _ := do("PUT", "/files/file.txt", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	Context("with listings", func() {
+		BeforeEach(func() {
+			options.Listings = true
+		})
+
+		It("should list folders", func() {
+			res, body := do("GET", "/files/", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+			Expect(body).To(ContainSubstring(`<a href="file.txt">file.txt</a>`))
+			Expect(body).To(ContainSubstring(`<a href="a%20b/">a b/</a>`))
+		})
+
+		It("should redirect folders to a trailing slash", func() {
+			res, _ := do("GET", "/files/a%20b", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusMovedPermanently))
+			Expect(res.Header.Get("Location")).To(Equal("a%20b/"))
+		})
+	})
+})
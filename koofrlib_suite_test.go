@@ -75,3 +75,10 @@ func TestKoofrclient(t *testing.T) {
 
 	RunSpecs(t, "Koofrclient Suite")
 }
+
+// Specs run in random order and most only write under rootPath, so it is
+// created once here. ClientFiles recreates it empty before each of its specs.
+var _ = BeforeSuite(func() {
+	err := client.FilesMkdirAll(defaultMountId, rootPath)
+	Expect(err).NotTo(HaveOccurred())
+})
@@ -0,0 +1,589 @@
+// Package koofrtest provides an in-memory Koofr API server for tests. It
+// implements the mounts endpoints and the files endpoints used by
+// koofrclient, including ranges, conditional gets and conditional puts and
+// deletes. Hashes are MD5 hex digests of the content.
+package koofrtest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+const PrimaryMountId = "koofr"
+
+type node struct {
+	dir      bool
+	data     []byte
+	modified int64
+}
+
+type Server struct {
+	*httptest.Server
+	mu     sync.Mutex
+	mounts []koofrclient.Mount
+	files  map[string]map[string]*node
+	fail   func(op string, p string) int
+}
+
+// NewServer starts a server with an empty primary mount named "Koofr" with
+// id PrimaryMountId and 1 GiB of space. Close must be called when done.
+func NewServer() *Server {
+	s := &Server{
+		files: make(map[string]map[string]*node),
+	}
+
+	s.AddMount(koofrclient.Mount{
+		Id:         PrimaryMountId,
+		Name:       "Koofr",
+		Type:       "device",
+		Online:     true,
+		SpaceTotal: 1 << 30,
+		IsPrimary:  true,
+	})
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// AddMount adds an empty mount. SpaceUsed is computed from the stored files.
+func (s *Server) AddMount(mount koofrclient.Mount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mounts = append(s.mounts, mount)
+	s.files[mount.Id] = map[string]*node{
+		"/": {dir: true, modified: now()},
+	}
+}
+
+// Client returns a client for the server.
+func (s *Server) Client() *koofrclient.KoofrClient {
+	return koofrclient.NewKoofrClient(s.URL, false)
+}
+
+// PutFile stores a file, creating missing parent folders.
+func (s *Server) PutFile(mountId string, p string, data []byte, modified int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files := s.files[mountId]
+	p = path.Clean("/" + p)
+
+	for dir := path.Dir(p); files[dir] == nil; dir = path.Dir(dir) {
+		files[dir] = &node{dir: true, modified: modified}
+	}
+
+	files[p] = &node{data: data, modified: modified}
+}
+
+// FailWith makes every request for the files operation op ("info", "copy",
+// "move", "remove", "put", ...) fail with status, without changing
+// anything. A status of 0 removes the failure.
+func (s *Server) FailWith(op string, status int) {
+	if status == 0 {
+		s.FailFunc(nil)
+		return
+	}
+
+	s.FailFunc(func(o string, p string) int {
+		if o == op {
+			return status
+		}
+		return 0
+	})
+}
+
+// FailFunc calls fail with the operation and path of every files request.
+// If it returns a status other than 0, the request fails with it. A nil
+// fail removes the failures.
+func (s *Server) FailFunc(fail func(op string, p string) int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fail = fail
+}
+
+// ReadFile returns the content of a file and whether it exists.
+func (s *Server) ReadFile(mountId string, p string) (data []byte, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.files[mountId][path.Clean("/"+p)]
+
+	if n == nil || n.dir {
+		return nil, false
+	}
+
+	return n.data, true
+}
+
+func now() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+func hashOf(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (n *node) info(p string) koofrclient.FileInfo {
+	info := koofrclient.FileInfo{
+		Name:     path.Base(p),
+		Type:     "dir",
+		Modified: n.modified,
+		Path:     p,
+	}
+
+	if p == "/" {
+		info.Name = ""
+	}
+
+	if !n.dir {
+		info.Type = "file"
+		info.Size = int64(len(n.data))
+		info.Hash = hashOf(n.data)
+		info.ContentType = contentType(p)
+	}
+
+	return info
+}
+
+func contentType(p string) string {
+	if t := mime.TypeByExtension(path.Ext(p)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func (s *Server) mount(id string) (mount koofrclient.Mount, ok bool) {
+	for _, m := range s.mounts {
+		if m.Id == id {
+			used := int64(0)
+			for _, n := range s.files[id] {
+				used += int64(len(n.data))
+			}
+			m.SpaceUsed = used
+			return m, true
+		}
+	}
+	return
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := strings.TrimPrefix(r.URL.Path, "/content")
+
+	if p == "/api/v2/mounts" {
+		mounts := make([]koofrclient.Mount, len(s.mounts))
+		for i, m := range s.mounts {
+			mounts[i], _ = s.mount(m.Id)
+		}
+		writeJSON(w, map[string]interface{}{"mounts": mounts})
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(p, "/api/v2/mounts/"), "/", 2)
+
+	mount, ok := s.mount(parts[0])
+
+	if !ok || !strings.HasPrefix(p, "/api/v2/mounts/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) == 1 {
+		writeJSON(w, mount)
+		return
+	}
+
+	files := s.files[mount.Id]
+	fp := path.Clean("/" + r.URL.Query().Get("path"))
+
+	if s.fail != nil {
+		if status := s.fail(strings.TrimPrefix(parts[1], "files/"), fp); status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+	}
+
+	switch r.Method + " " + parts[1] {
+	case "GET files/info":
+		s.info(w, files, fp)
+	case "GET files/list":
+		s.list(w, files, fp)
+	case "GET files/tree":
+		s.tree(w, files, fp)
+	case "POST files/folder":
+		s.folder(w, r, files, fp)
+	case "DELETE files/remove":
+		s.remove(w, r, files, fp)
+	case "PUT files/copy", "PUT files/move":
+		s.copy(w, r, mount.Id, files, fp, parts[1] == "files/move")
+	case "GET files/get":
+		s.get(w, r, files, fp)
+	case "POST files/put":
+		s.put(w, r, mount, files, fp)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func children(files map[string]*node, p string) (paths []string) {
+	for k := range files {
+		if k != "/" && path.Dir(k) == p {
+			paths = append(paths, k)
+		}
+	}
+	sort.Strings(paths)
+	return
+}
+
+func (s *Server) info(w http.ResponseWriter, files map[string]*node, p string) {
+	n := files[p]
+
+	if n == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, n.info(p))
+}
+
+func (s *Server) list(w http.ResponseWriter, files map[string]*node, p string) {
+	n := files[p]
+
+	if n == nil || !n.dir {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	infos := []koofrclient.FileInfo{}
+	for _, child := range children(files, p) {
+		infos = append(infos, files[child].info(child))
+	}
+
+	writeJSON(w, map[string]interface{}{"files": infos})
+}
+
+func (s *Server) tree(w http.ResponseWriter, files map[string]*node, p string) {
+	if files[p] == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var tree func(p string) *koofrclient.FileTree
+	tree = func(p string) *koofrclient.FileTree {
+		t := &koofrclient.FileTree{FileInfo: files[p].info(p), Children: []*koofrclient.FileTree{}}
+		for _, child := range children(files, p) {
+			t.Children = append(t.Children, tree(child))
+		}
+		return t
+	}
+
+	writeJSON(w, tree(p))
+}
+
+func (s *Server) folder(w http.ResponseWriter, r *http.Request, files map[string]*node, p string) {
+	var req koofrclient.FolderCreate
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if parent := files[p]; parent == nil || !parent.dir {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	np := path.Join(p, req.Name)
+
+	if files[np] != nil {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	files[np] = &node{dir: true, modified: now()}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) remove(w http.ResponseWriter, r *http.Request, files map[string]*node, p string) {
+	n := files[p]
+
+	if n == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if p == "/" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	info := n.info(p)
+
+	if !matches(query, "removeIf", info) ||
+		(query["removeIfEmpty"] != nil && len(children(files, p)) > 0) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	for k := range files {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			delete(files, k)
+		}
+	}
+}
+
+// matches checks the <prefix>Size, <prefix>Modified and <prefix>Hash
+// conditions against info.
+func matches(query map[string][]string, prefix string, info koofrclient.FileInfo) bool {
+	values := map[string]string{
+		"Size":     strconv.FormatInt(info.Size, 10),
+		"Modified": strconv.FormatInt(info.Modified, 10),
+		"Hash":     info.Hash,
+	}
+
+	for suffix, value := range values {
+		if v, ok := query[prefix+suffix]; ok && v[0] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *Server) copy(w http.ResponseWriter, r *http.Request, mountId string, files map[string]*node, p string, move bool) {
+	var req koofrclient.FileCopy
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	to := s.files[req.ToMountId]
+	toPath := path.Clean("/" + req.TPath)
+
+	switch {
+	case files[p] == nil || to == nil:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case p == "/" || toPath == "/":
+		w.WriteHeader(http.StatusForbidden)
+		return
+	case to[toPath] != nil:
+		w.WriteHeader(http.StatusConflict)
+		return
+	case to[path.Dir(toPath)] == nil || !to[path.Dir(toPath)].dir:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case req.ToMountId == mountId && strings.HasPrefix(toPath, p+"/"):
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for k, n := range files {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			c := *n
+			to[path.Join(toPath, strings.TrimPrefix(k, p))] = &c
+			if move {
+				delete(files, k)
+			}
+		}
+	}
+
+	if req.Modified != nil {
+		to[toPath].modified = *req.Modified
+	}
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, files map[string]*node, p string) {
+	n := files[p]
+
+	if n == nil || n.dir {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	hash := hashOf(n.data)
+	modified := time.Unix(0, n.modified*int64(time.Millisecond)).UTC()
+
+	w.Header().Set("Content-Type", contentType(p))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(p)}))
+	w.Header().Set("ETag", `"`+hash+`"`)
+	w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if strings.Trim(inm, `"`) == hash {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.Truncate(time.Second).After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	start, end, ok := parseRange(r.Header.Get("Range"), int64(len(n.data)))
+
+	if !ok {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(n.data)))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	if start == 0 && end == int64(len(n.data))-1 && r.Header.Get("Range") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(len(n.data)))
+		w.Write(n.data)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(n.data)))
+	w.WriteHeader(http.StatusPartialContent)
+	w.Write(n.data[start : end+1])
+}
+
+// parseRange parses a single "bytes=start-end", "bytes=start-" or
+// "bytes=-suffix" range. An empty header selects the whole content.
+func parseRange(header string, size int64) (start int64, end int64, ok bool) {
+	if header == "" {
+		return 0, size - 1, true
+	}
+
+	bounds := strings.SplitN(strings.TrimPrefix(header, "bytes="), "-", 2)
+
+	if len(bounds) != 2 {
+		return 0, 0, false
+	}
+
+	var err error
+
+	if bounds[0] == "" {
+		suffix, err := strconv.ParseInt(bounds[1], 10, 64)
+		if err != nil || suffix <= 0 {
+			return 0, 0, false
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return size - suffix, size - 1, true
+	}
+
+	if start, err = strconv.ParseInt(bounds[0], 10, 64); err != nil || start >= size {
+		return 0, 0, false
+	}
+
+	end = size - 1
+
+	if bounds[1] != "" {
+		if end, err = strconv.ParseInt(bounds[1], 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return start, end, true
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request, mount koofrclient.Mount, files map[string]*node, p string) {
+	query := r.URL.Query()
+	name := query.Get("filename")
+
+	if parent := files[p]; parent == nil || !parent.dir {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	part, _, err := r.FormFile("file")
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	data, err := ioutil.ReadAll(part)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if mount.SpaceTotal > 0 && mount.SpaceUsed+int64(len(data)) > mount.SpaceTotal {
+		w.WriteHeader(http.StatusInsufficientStorage)
+		return
+	}
+
+	np := path.Join(p, name)
+	existing := files[np]
+	conditional := false
+
+	for _, key := range []string{"overwriteIfSize", "overwriteIfModified", "overwriteIfHash"} {
+		if _, ok := query[key]; ok {
+			conditional = true
+		}
+	}
+
+	switch {
+	case existing != nil && existing.dir:
+		w.WriteHeader(http.StatusConflict)
+		return
+	case existing != nil && conditional:
+		if !matches(query, "overwriteIf", existing.info(np)) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+	case existing == nil && conditional:
+		if _, ok := query["overwriteIgnoreNonexisting"]; !ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+	case existing != nil && query.Get("overwrite") != "true":
+		if query.Get("autorename") == "false" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		ext := path.Ext(name)
+		for i := 1; files[np] != nil; i++ {
+			np = path.Join(p, fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), i, ext))
+		}
+	}
+
+	modified := now()
+
+	if v := query.Get("modified"); v != "" {
+		if modified, err = strconv.ParseInt(v, 10, 64); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	files[np] = &node{data: data, modified: modified}
+
+	writeJSON(w, files[np].info(np))
+}
@@ -0,0 +1,13 @@
+package koofrtest_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestKoofrtest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Koofrtest Suite")
+}
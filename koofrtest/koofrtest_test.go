@@ -0,0 +1,121 @@
+package koofrtest_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/koofr/go-httpclient"
+	koofrclient "github.com/koofr/go-koofrclient"
+	"github.com/koofr/go-koofrclient/koofrtest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", func() {
+	var server *koofrtest.Server
+	var client *koofrclient.KoofrClient
+
+	BeforeEach(func() {
+		server = koofrtest.NewServer()
+		client = server.Client()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should list mounts", func() {
+		server.AddMount(koofrclient.Mount{Id: "dropbox", Name: "Dropbox", SpaceTotal: 100})
+		server.PutFile("dropbox", "/a.txt", []byte("content"), 1000)
+
+		mounts, err := client.Mounts()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mounts).To(HaveLen(2))
+		Expect(mounts[0].Id).To(Equal(koofrtest.PrimaryMountId))
+		Expect(mounts[0].IsPrimary).To(BeTrue())
+		Expect(mounts[1].SpaceUsed).To(Equal(int64(7)))
+	})
+
+	It("should put, get and list files", func() {
+		modified := int64(1562663291000)
+		info, err := client.FilesPutWithOptions(koofrtest.PrimaryMountId, "/", "file.txt", bytes.NewReader([]byte("content")), &koofrclient.PutOptions{SetModified: &modified})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size).To(Equal(int64(7)))
+		Expect(info.Hash).To(Equal("9a0364b9e99bb480dd25e1f0284c8555"))
+		Expect(info.ContentType).To(HavePrefix("text/plain"))
+
+		content, err := client.FilesGetWithInfo(koofrtest.PrimaryMountId, "/file.txt", &koofrclient.FileSpan{Start: 2, End: 3})
+		Expect(err).NotTo(HaveOccurred())
+		data, _ := ioutil.ReadAll(content)
+		content.Close()
+		Expect(data).To(Equal([]byte("nt")))
+		Expect(content.Size).To(Equal(int64(7)))
+		Expect(content.Name).To(Equal("file.txt"))
+		Expect(content.Modified).To(Equal(modified))
+
+		_, err = client.FilesGetWithOptions(koofrtest.PrimaryMountId, "/file.txt", &koofrclient.GetOptions{IfNoneMatch: &info.Hash})
+		Expect(err).To(Equal(koofrclient.ErrNotModified))
+
+		files, err := client.FilesList(koofrtest.PrimaryMountId, "/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Name).To(Equal("file.txt"))
+	})
+
+	It("should rename or reject conflicting uploads", func() {
+		server.PutFile(koofrtest.PrimaryMountId, "/file.txt", []byte("old"), 1000)
+
+		info, err := client.FilesPutWithOptions(koofrtest.PrimaryMountId, "/", "file.txt", bytes.NewReader([]byte("new")), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Name).To(Equal("file (1).txt"))
+
+		_, err = client.FilesPutWithOptions(koofrtest.PrimaryMountId, "/", "file.txt", bytes.NewReader([]byte("new")), &koofrclient.PutOptions{NoRename: true})
+		Expect(err).To(Equal(koofrclient.ErrCannotOverwrite))
+
+		size := int64(3)
+		_, err = client.FilesPutWithOptions(koofrtest.PrimaryMountId, "/", "file.txt", bytes.NewReader([]byte("newer")), &koofrclient.PutOptions{OverwriteIfSize: &size})
+		Expect(err).NotTo(HaveOccurred())
+
+		data, ok := server.ReadFile(koofrtest.PrimaryMountId, "/file.txt")
+		Expect(ok).To(BeTrue())
+		Expect(data).To(Equal([]byte("newer")))
+	})
+
+	It("should copy, move and delete folders", func() {
+		server.PutFile(koofrtest.PrimaryMountId, "/dir/sub/file.txt", []byte("content"), 1000)
+
+		err := client.FilesCopy(koofrtest.PrimaryMountId, "/dir", koofrtest.PrimaryMountId, "/copy", koofrclient.CopyOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		err = client.FilesMove(koofrtest.PrimaryMountId, "/dir", koofrtest.PrimaryMountId, "/moved")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, ok := server.ReadFile(koofrtest.PrimaryMountId, "/copy/sub/file.txt")
+		Expect(ok).To(BeTrue())
+		_, ok = server.ReadFile(koofrtest.PrimaryMountId, "/moved/sub/file.txt")
+		Expect(ok).To(BeTrue())
+		_, err = client.FilesInfo(koofrtest.PrimaryMountId, "/dir")
+		Expect(err).To(HaveOccurred())
+
+		err = client.FilesDeleteWithOptions(koofrtest.PrimaryMountId, "/copy", &koofrclient.DeleteOptions{RemoveIfEmpty: true})
+		Expect(err).To(Equal(koofrclient.ErrCannotRemove))
+		err = client.FilesDelete(koofrtest.PrimaryMountId, "/copy")
+		Expect(err).NotTo(HaveOccurred())
+		_, ok = server.ReadFile(koofrtest.PrimaryMountId, "/copy/sub/file.txt")
+		Expect(ok).To(BeFalse())
+	})
+	It("should fail operations on request", func() {
+		server.PutFile(koofrtest.PrimaryMountId, "/a.txt", []byte("content"), 1000)
+		server.FailWith("copy", http.StatusInternalServerError)
+
+		err := client.FilesCopy(koofrtest.PrimaryMountId, "/a.txt", koofrtest.PrimaryMountId, "/b.txt", koofrclient.CopyOptions{})
+		Expect(httpclient.IsInvalidStatusCode(err, http.StatusInternalServerError)).To(BeTrue())
+		_, ok := server.ReadFile(koofrtest.PrimaryMountId, "/b.txt")
+		Expect(ok).To(BeFalse())
+
+		server.FailWith("copy", 0)
+
+		err = client.FilesCopy(koofrtest.PrimaryMountId, "/a.txt", koofrtest.PrimaryMountId, "/b.txt", koofrclient.CopyOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,179 @@
+package koofrclient
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const PrimaryMountName = "primary"
+
+var ErrMountNotFound = fmt.Errorf("Mount not found")
+
+// ParseMountPath splits a mount path into the mount name and a cleaned
+// absolute path. Accepted forms are koofr://Name/path (with the name and path
+// percent-encoded as in URLs), Name:/path and /path. An empty mount name
+// refers to the primary mount.
+func ParseMountPath(uri string) (mountName string, p string, err error) {
+	switch {
+	case strings.HasPrefix(uri, "koofr://"):
+		rest := strings.TrimPrefix(uri, "koofr://")
+		mountName, rest = rest, ""
+		if i := strings.Index(mountName, "/"); i >= 0 {
+			mountName, rest = mountName[:i], mountName[i:]
+		}
+		if mountName, err = url.PathUnescape(mountName); err != nil {
+			return "", "", err
+		}
+		if rest, err = url.PathUnescape(rest); err != nil {
+			return "", "", err
+		}
+		return mountName, path.Clean("/" + rest), nil
+
+	case strings.HasPrefix(uri, "/"):
+		return "", path.Clean(uri), nil
+
+	default:
+		i := strings.Index(uri, ":")
+		if i < 0 {
+			return "", "", fmt.Errorf("Invalid mount path: %s", uri)
+		}
+		return uri[:i], path.Clean("/" + uri[i+1:]), nil
+	}
+}
+
+// MountResolver maps mount names to mount ids. The mount list is fetched
+// once and cached; it is refreshed automatically when a name is not found.
+type MountResolver struct {
+	client *KoofrClient
+	mu     sync.Mutex
+	mounts []Mount
+}
+
+func NewMountResolver(client *KoofrClient) *MountResolver {
+	return &MountResolver{
+		client: client,
+	}
+}
+
+func (r *MountResolver) Refresh() (err error) {
+	mounts, err := r.client.Mounts()
+
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.mounts = mounts
+	r.mu.Unlock()
+
+	return
+}
+
+func (r *MountResolver) cached() (mounts []Mount, err error) {
+	r.mu.Lock()
+	mounts = r.mounts
+	r.mu.Unlock()
+
+	if mounts != nil {
+		return
+	}
+
+	if err = r.Refresh(); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	mounts = r.mounts
+	r.mu.Unlock()
+
+	return
+}
+
+// Mount finds a mount by id, by name or, for an empty name or "primary", the
+// primary mount. A mount with the id or name "primary" is found before the
+// primary mount. When several mounts share a name, the primary mount wins,
+// then mounts that are not shared with the user, then the lowest id.
+func (r *MountResolver) Mount(name string) (mount Mount, err error) {
+	mounts, err := r.cached()
+
+	if err != nil {
+		return
+	}
+
+	mount, ok := findMount(mounts, name)
+
+	if !ok {
+		if err = r.Refresh(); err != nil {
+			return
+		}
+
+		r.mu.Lock()
+		mount, ok = findMount(r.mounts, name)
+		r.mu.Unlock()
+	}
+
+	if !ok {
+		return Mount{}, ErrMountNotFound
+	}
+
+	return mount, nil
+}
+
+// Resolve parses uri with ParseMountPath and returns the mount id and path to
+// pass to the Files methods.
+func (r *MountResolver) Resolve(uri string) (mountId string, p string, err error) {
+	mountName, p, err := ParseMountPath(uri)
+
+	if err != nil {
+		return
+	}
+
+	mount, err := r.Mount(mountName)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return mount.Id, p, nil
+}
+
+func findMount(mounts []Mount, name string) (mount Mount, ok bool) {
+	candidates := []Mount{}
+
+	for _, m := range mounts {
+		if name == "" {
+			if m.IsPrimary {
+				return m, true
+			}
+		} else if m.Id == name {
+			return m, true
+		} else if m.Name == name {
+			candidates = append(candidates, m)
+		}
+	}
+
+	if len(candidates) == 0 {
+		if name == PrimaryMountName {
+			return findMount(mounts, "")
+		}
+
+		return Mount{}, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.IsPrimary != b.IsPrimary {
+			return a.IsPrimary
+		}
+		if a.IsShared != b.IsShared {
+			return !a.IsShared
+		}
+		return a.Id < b.Id
+	})
+
+	return candidates[0], true
+}
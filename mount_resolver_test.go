@@ -0,0 +1,60 @@
+package koofrclient_test
+
+import (
+	k "github.com/koofr/go-koofrclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MountResolver", func() {
+	It("should parse mount paths", func() {
+		mountName, p, err := k.ParseMountPath("koofr://Dropbox/docs/a.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mountName).To(Equal("Dropbox"))
+		Expect(p).To(Equal("/docs/a.txt"))
+
+		mountName, p, err = k.ParseMountPath("koofr://My%20Drive")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mountName).To(Equal("My Drive"))
+		Expect(p).To(Equal("/"))
+
+		mountName, p, err = k.ParseMountPath("primary:photos//2019/../2020")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mountName).To(Equal("primary"))
+		Expect(p).To(Equal("/photos/2020"))
+
+		mountName, p, err = k.ParseMountPath("/a:b.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mountName).To(Equal(""))
+		Expect(p).To(Equal("/a:b.txt"))
+
+		_, _, err = k.ParseMountPath("relative/path")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should resolve the primary mount", func() {
+		resolver := k.NewMountResolver(client)
+		mountId, p, err := resolver.Resolve("primary:" + rootPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mountId).To(Equal(defaultMountId))
+		Expect(p).To(Equal(rootPath))
+		mountId, _, err = resolver.Resolve(rootPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mountId).To(Equal(defaultMountId))
+	})
+
+	It("should resolve mounts by name and id", func() {
+		resolver := k.NewMountResolver(client)
+		mount, err := resolver.Mount(defaultMountId)
+		Expect(err).NotTo(HaveOccurred())
+		mountId, _, err := resolver.Resolve(mount.Name + ":/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mountId).To(Equal(defaultMountId))
+	})
+
+	It("should fail for unknown mounts", func() {
+		resolver := k.NewMountResolver(client)
+		_, _, err := resolver.Resolve("koofr://does-not-exist-mount/x")
+		Expect(err).To(Equal(k.ErrMountNotFound))
+	})
+})
@@ -0,0 +1,305 @@
+// Package otelkoofr instruments a KoofrClient with OpenTelemetry tracing and
+// metrics.
+package otelkoofr
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const InstrumentationName = "github.com/koofr/go-koofrclient/otelkoofr"
+
+const (
+	OperationKey     = attribute.Key("koofr.operation")
+	MountIdKey       = attribute.Key("koofr.mount_id")
+	PathKey          = attribute.Key("koofr.path")
+	BytesSentKey     = attribute.Key("koofr.bytes_sent")
+	BytesReceivedKey = attribute.Key("koofr.bytes_received")
+	MethodKey        = attribute.Key("http.request.method")
+	StatusCodeKey    = attribute.Key("http.response.status_code")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagators    propagation.TextMapPropagator
+}
+
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider. Defaults to the global one.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider. Defaults to the global one.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithPropagators sets the propagators used to send the trace context to
+// the server. Defaults to the global ones.
+func WithPropagators(propagators propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagators = propagators
+	}
+}
+
+// Instrument makes client create a span and record metrics for every API
+// call. Spans are children of the span in the context given to
+// KoofrClient.WithContext. For downloads the span ends when the body is
+// read to the end or closed.
+func Instrument(client *koofrclient.KoofrClient, opts ...Option) (err error) {
+	httpClient := *client.HTTPClient.Client
+
+	transport, err := NewTransport(httpClient.Transport, opts...)
+
+	if err != nil {
+		return
+	}
+
+	httpClient.Transport = transport
+	client.HTTPClient.Client = &httpClient
+
+	return
+}
+
+type Transport struct {
+	base          http.RoundTripper
+	tracer        trace.Tracer
+	propagators   propagation.TextMapPropagator
+	duration      metric.Float64Histogram
+	errors        metric.Int64Counter
+	bytesSent     metric.Int64Counter
+	bytesReceived metric.Int64Counter
+}
+
+// NewTransport wraps base (http.DefaultTransport if nil) with
+// instrumentation.
+func NewTransport(base http.RoundTripper, opts ...Option) (t *Transport, err error) {
+	c := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagators:    otel.GetTextMapPropagator(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	meter := c.meterProvider.Meter(InstrumentationName)
+
+	t = &Transport{
+		base:        base,
+		tracer:      c.tracerProvider.Tracer(InstrumentationName),
+		propagators: c.propagators,
+	}
+
+	if t.duration, err = meter.Float64Histogram("koofr.client.request.duration", metric.WithUnit("s"), metric.WithDescription("Duration of Koofr API calls")); err != nil {
+		return nil, err
+	}
+	if t.errors, err = meter.Int64Counter("koofr.client.request.errors", metric.WithDescription("Failed Koofr API calls")); err != nil {
+		return nil, err
+	}
+	if t.bytesSent, err = meter.Int64Counter("koofr.client.bytes_sent", metric.WithUnit("By"), metric.WithDescription("Bytes uploaded to Koofr")); err != nil {
+		return nil, err
+	}
+	if t.bytesReceived, err = meter.Int64Counter("koofr.client.bytes_received", metric.WithUnit("By"), metric.WithDescription("Bytes downloaded from Koofr")); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	start := time.Now()
+
+	p := requestPath(req.URL)
+	operation := OperationName(req.Method, p)
+
+	attrs := []attribute.KeyValue{
+		OperationKey.String(operation),
+		MethodKey.String(req.Method),
+	}
+
+	if mountId := mountId(p); mountId != "" {
+		attrs = append(attrs, MountIdKey.String(mountId))
+	}
+
+	if filePath := req.URL.Query().Get("path"); filePath != "" {
+		attrs = append(attrs, PathKey.String(filePath))
+	}
+
+	ctx, span := t.tracer.Start(req.Context(), operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+	req = req.Clone(ctx)
+	t.propagators.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	var sent *countingBody
+
+	if req.Body != nil && req.Body != http.NoBody {
+		sent = &countingBody{ReadCloser: req.Body}
+		req.Body = sent
+	}
+
+	finish := func(status int, received int64, err error) {
+		metricAttrs := []attribute.KeyValue{OperationKey.String(operation), StatusCodeKey.Int(status)}
+		opt := metric.WithAttributes(metricAttrs...)
+
+		sentBytes := int64(0)
+		if sent != nil {
+			sentBytes = sent.n.Load()
+		}
+
+		t.duration.Record(ctx, time.Since(start).Seconds(), opt)
+		t.bytesSent.Add(ctx, sentBytes, opt)
+		t.bytesReceived.Add(ctx, received, opt)
+
+		span.SetAttributes(BytesSentKey.Int64(sentBytes), BytesReceivedKey.Int64(received))
+
+		if err != nil || status >= 400 {
+			t.errors.Add(ctx, 1, opt)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		}
+
+		span.End()
+	}
+
+	res, err = t.base.RoundTrip(req)
+
+	if err != nil {
+		finish(0, 0, err)
+		return
+	}
+
+	span.SetAttributes(StatusCodeKey.Int(res.StatusCode))
+
+	res.Body = &countingBody{
+		ReadCloser: res.Body,
+		onDone: func(n int64, err error) {
+			finish(res.StatusCode, n, err)
+		},
+	}
+
+	return
+}
+
+// countingBody counts the bytes read. Request bodies are read by the
+// transport in another goroutine, so n may be loaded while it still grows.
+type countingBody struct {
+	io.ReadCloser
+	n      atomic.Int64
+	onDone func(n int64, err error)
+	done   bool
+}
+
+func (b *countingBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+
+	if err == io.EOF {
+		b.finish(nil)
+	} else if err != nil {
+		b.finish(err)
+	}
+
+	return
+}
+
+func (b *countingBody) Close() error {
+	b.finish(nil)
+	return b.ReadCloser.Close()
+}
+
+func (b *countingBody) finish(err error) {
+	if b.done || b.onDone == nil {
+		return
+	}
+	b.done = true
+	b.onDone(b.n.Load(), err)
+}
+
+// requestPath returns the unescaped request path. The Koofr client sends
+// escaped paths in URL.Opaque.
+func requestPath(u *url.URL) string {
+	if u.Path != "" {
+		return u.Path
+	}
+
+	if p, err := url.PathUnescape(u.Opaque); err == nil {
+		return p
+	}
+
+	return u.Opaque
+}
+
+func apiPath(p string) string {
+	if i := strings.Index(p, "/api/v2/"); i >= 0 {
+		return p[i+len("/api/v2/"):]
+	}
+	return strings.TrimPrefix(p, "/")
+}
+
+func mountId(p string) string {
+	parts := strings.Split(apiPath(p), "/")
+
+	if len(parts) >= 2 && parts[0] == "mounts" {
+		return parts[1]
+	}
+
+	return ""
+}
+
+// OperationName maps an API call to a span name like koofr.files.put or
+// koofr.devices.create.
+func OperationName(method string, p string) string {
+	parts := strings.Split(apiPath(p), "/")
+
+	switch {
+	case len(parts) >= 4 && parts[0] == "mounts" && parts[2] == "files":
+		return "koofr.files." + parts[3]
+	case len(parts) == 1:
+		if method == http.MethodPost && parts[0] != "token" {
+			return "koofr." + parts[0] + ".create"
+		}
+		return "koofr." + parts[0]
+	}
+
+	action := "details"
+
+	switch method {
+	case http.MethodPut, http.MethodPatch:
+		action = "update"
+	case http.MethodDelete:
+		action = "delete"
+	case http.MethodPost:
+		action = "create"
+	}
+
+	return "koofr." + parts[0] + "." + action
+}
@@ -0,0 +1,13 @@
+package otelkoofr_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestOtelkoofr(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Otelkoofr Suite")
+}
@@ -0,0 +1,141 @@
+package otelkoofr_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+	"github.com/koofr/go-koofrclient/otelkoofr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var _ = Describe("Otelkoofr", func() {
+	var server *httptest.Server
+	var traceparents []string
+	var recorder *tracetest.SpanRecorder
+	var tracerProvider *sdktrace.TracerProvider
+	var reader *sdkmetric.ManualReader
+	var client *koofrclient.KoofrClient
+
+	BeforeEach(func() {
+		traceparents = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceparents = append(traceparents, r.Header.Get("Traceparent"))
+			switch r.URL.Path {
+			case "/api/v2/mounts/m1/files/info":
+				w.Write([]byte(`{"name":"file.txt","type":"file","size":7}`))
+			case "/content/api/v2/mounts/m1/files/get":
+				w.Write([]byte("content"))
+			case "/content/api/v2/mounts/m1/files/put":
+				ioutil.ReadAll(r.Body)
+				w.Write([]byte(`{"name":"file.txt"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		recorder = tracetest.NewSpanRecorder()
+		tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		reader = sdkmetric.NewManualReader()
+
+		client = koofrclient.NewKoofrClient(server.URL, false)
+		err := otelkoofr.Instrument(client,
+			otelkoofr.WithTracerProvider(tracerProvider),
+			otelkoofr.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+			otelkoofr.WithPropagators(propagation.TraceContext{}),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	attr := func(attrs []attribute.KeyValue, key attribute.Key) attribute.Value {
+		for _, kv := range attrs {
+			if kv.Key == key {
+				return kv.Value
+			}
+		}
+		return attribute.Value{}
+	}
+
+	It("should create a span per call as a child of the context span", func() {
+		ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "parent")
+		_, err := client.WithContext(ctx).FilesInfo("m1", "/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		parent.End()
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+		span := spans[0]
+		Expect(span.Name()).To(Equal("koofr.files.info"))
+		Expect(span.Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+		Expect(attr(span.Attributes(), otelkoofr.MountIdKey).AsString()).To(Equal("m1"))
+		Expect(attr(span.Attributes(), otelkoofr.PathKey).AsString()).To(Equal("/file.txt"))
+		Expect(attr(span.Attributes(), otelkoofr.StatusCodeKey).AsInt64()).To(Equal(int64(200)))
+		Expect(traceparents[0]).To(ContainSubstring(parent.SpanContext().TraceID().String()))
+	})
+
+	It("should end download spans when the body is read", func() {
+		body, err := client.FilesGet("m1", "/file.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Ended()).To(BeEmpty())
+		content, err := ioutil.ReadAll(body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal([]byte("content")))
+		body.Close()
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name()).To(Equal("koofr.files.get"))
+		Expect(attr(spans[0].Attributes(), otelkoofr.BytesReceivedKey).AsInt64()).To(Equal(int64(7)))
+	})
+
+	It("should mark failed calls and record metrics", func() {
+		_, err := client.FilesPut("m1", "/", "file.txt", bytes.NewReader([]byte("content")))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.FilesList("m1", "/missing")
+		Expect(err).To(HaveOccurred())
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Name()).To(Equal("koofr.files.put"))
+		Expect(attr(spans[0].Attributes(), otelkoofr.BytesSentKey).AsInt64()).To(BeNumerically(">", 7))
+		Expect(spans[1].Status().Code).To(Equal(codes.Error))
+
+		var rm metricdata.ResourceMetrics
+		Expect(reader.Collect(context.Background(), &rm)).To(Succeed())
+		names := map[string]metricdata.Aggregation{}
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				names[m.Name] = m.Data
+			}
+		}
+		Expect(names).To(HaveKey("koofr.client.request.duration"))
+		Expect(names).To(HaveKey("koofr.client.bytes_sent"))
+		errors := names["koofr.client.request.errors"].(metricdata.Sum[int64])
+		Expect(errors.DataPoints).To(HaveLen(1))
+		Expect(errors.DataPoints[0].Value).To(Equal(int64(1)))
+	})
+
+	It("should name operations", func() {
+		Expect(otelkoofr.OperationName("POST", "/token")).To(Equal("koofr.token"))
+		Expect(otelkoofr.OperationName("GET", "/api/v2/mounts")).To(Equal("koofr.mounts"))
+		Expect(otelkoofr.OperationName("GET", "/api/v2/mounts/m1")).To(Equal("koofr.mounts.details"))
+		Expect(otelkoofr.OperationName("POST", "/api/v2/devices")).To(Equal("koofr.devices.create"))
+		Expect(otelkoofr.OperationName("DELETE", "/api/v2/devices/d1")).To(Equal("koofr.devices.delete"))
+		Expect(otelkoofr.OperationName("POST", "/content/api/v2/mounts/m1/files/put")).To(Equal("koofr.files.put"))
+	})
+})
@@ -0,0 +1,245 @@
+// Package retention prunes backups stored as files or folders in a Koofr
+// folder with grandfather-father-son rules.
+package retention
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+var ErrEmptyPolicy = fmt.Errorf("Policy keeps nothing")
+var ErrNoSelection = fmt.Errorf("Pattern or TimeFormat is required to select backups")
+
+// Policy is the number of backups kept by each rule. A backup is kept if
+// any rule keeps it. Last keeps the newest backups. Daily, Weekly, Monthly
+// and Yearly keep the newest backup of each of that many most recent days,
+// ISO weeks, months and years that have backups.
+type Policy struct {
+	Last    int
+	Daily   int
+	Weekly  int
+	Monthly int
+	Yearly  int
+}
+
+func (p Policy) empty() bool {
+	return p.Last <= 0 && p.Daily <= 0 && p.Weekly <= 0 && p.Monthly <= 0 && p.Yearly <= 0
+}
+
+type Options struct {
+	Policy
+	// Pattern limits the backups to names matching it (path.Match syntax).
+	Pattern string
+	// TimeFormat is a numeric time layout, such as "2006-01-02_150405",
+	// used to find the backup time in its name. Names without a match are
+	// left alone. If empty, FileInfo.Modified is used.
+	TimeFormat string
+	// All allows pruning without Pattern and TimeFormat, treating every file
+	// and folder in the folder as a backup dated by its modified time.
+	All bool
+	// Location is used for parsing names and for the day boundaries.
+	// Defaults to time.Local.
+	Location *time.Location
+	// DryRun only returns the decisions.
+	DryRun bool
+}
+
+// Backup is a file or folder with its backup time. Info.Path is the full
+// path on the mount.
+type Backup struct {
+	Info koofrclient.FileInfo
+	Time time.Time
+}
+
+type Decision struct {
+	Backup
+	Keep bool
+	// Reasons are the rules keeping the backup: "last", "daily", "weekly",
+	// "monthly" or "yearly".
+	Reasons []string
+}
+
+func (d Decision) String() string {
+	if !d.Keep {
+		return fmt.Sprintf("remove %s %s", d.Time.Format(time.RFC3339), d.Info.Path)
+	}
+
+	return fmt.Sprintf("keep   %s %s (%s)", d.Time.Format(time.RFC3339), d.Info.Path, strings.Join(d.Reasons, ", "))
+}
+
+type rule struct {
+	name  string
+	count int
+	key   func(t time.Time) string
+}
+
+// Decide applies the policy to backups. Decisions are returned newest
+// first.
+func (p Policy) Decide(backups []Backup) []Decision {
+	decisions := make([]Decision, len(backups))
+
+	for i, backup := range backups {
+		decisions[i].Backup = backup
+	}
+
+	sort.SliceStable(decisions, func(i, j int) bool {
+		return decisions[i].Time.After(decisions[j].Time)
+	})
+
+	rules := []rule{
+		{"last", p.Last, nil},
+		{"daily", p.Daily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{"weekly", p.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		}},
+		{"monthly", p.Monthly, func(t time.Time) string { return t.Format("2006-01") }},
+		{"yearly", p.Yearly, func(t time.Time) string { return t.Format("2006") }},
+	}
+
+	for _, r := range rules {
+		left := r.count
+		last := ""
+
+		for i := range decisions {
+			if left <= 0 {
+				break
+			}
+
+			if r.key != nil {
+				key := r.key(decisions[i].Time)
+
+				if key == last {
+					continue
+				}
+
+				last = key
+			}
+
+			decisions[i].Keep = true
+			decisions[i].Reasons = append(decisions[i].Reasons, r.name)
+			left--
+		}
+	}
+
+	return decisions
+}
+
+// List returns the backups in dir.
+func List(client *koofrclient.KoofrClient, mountId string, dir string, options *Options) (backups []Backup, err error) {
+	opts := defaults(options)
+
+	files, err := client.FilesList(mountId, dir)
+
+	if err != nil {
+		return
+	}
+
+	backups = []Backup{}
+
+	for _, info := range files {
+		if opts.Pattern != "" {
+			if ok, err := path.Match(opts.Pattern, info.Name); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+		}
+
+		t, ok := backupTime(info, opts)
+
+		if !ok {
+			continue
+		}
+
+		info.Path = path.Join(dir, info.Name)
+
+		backups = append(backups, Backup{info, t})
+	}
+
+	return
+}
+
+// Prune lists the backups in dir and deletes the ones the policy does not
+// keep. Unless Options.All is set, backups must be selected with a Pattern
+// or TimeFormat, so unrelated entries are never deleted. Files are deleted
+// only if they did not change since they were listed. It returns the
+// decisions, newest first, and stops at the first failed delete.
+func Prune(client *koofrclient.KoofrClient, mountId string, dir string, options *Options) (decisions []Decision, err error) {
+	opts := defaults(options)
+
+	if opts.Policy.empty() {
+		return nil, ErrEmptyPolicy
+	}
+
+	if opts.Pattern == "" && opts.TimeFormat == "" && !opts.All {
+		return nil, ErrNoSelection
+	}
+
+	backups, err := List(client, mountId, dir, &opts)
+
+	if err != nil {
+		return
+	}
+
+	decisions = opts.Policy.Decide(backups)
+
+	if opts.DryRun {
+		return
+	}
+
+	for _, d := range decisions {
+		if d.Keep {
+			continue
+		}
+
+		var deleteOptions *koofrclient.DeleteOptions
+
+		if d.Info.Type == "file" {
+			deleteOptions = &koofrclient.DeleteOptions{RemoveIfHash: &d.Info.Hash}
+		}
+
+		if err = client.FilesDeleteWithOptions(mountId, d.Info.Path, deleteOptions); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func defaults(options *Options) Options {
+	opts := Options{}
+
+	if options != nil {
+		opts = *options
+	}
+
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+
+	return opts
+}
+
+// backupTime finds the time in the name, trying every substring as long as
+// the layout, or takes the modified time.
+func backupTime(info koofrclient.FileInfo, opts Options) (t time.Time, ok bool) {
+	if opts.TimeFormat == "" {
+		return time.Unix(0, info.Modified*int64(time.Millisecond)).In(opts.Location), true
+	}
+
+	n := len(opts.TimeFormat)
+
+	for i := 0; i+n <= len(info.Name); i++ {
+		if t, err := time.ParseInLocation(opts.TimeFormat, info.Name[i:i+n], opts.Location); err == nil {
+			return t, true
+		}
+	}
+
+	return
+}
@@ -0,0 +1,143 @@
+package retention_test
+
+import (
+	"time"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+	"github.com/koofr/go-koofrclient/koofrtest"
+	"github.com/koofr/go-koofrclient/retention"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retention", func() {
+	day := func(s string) time.Time {
+		t, err := time.ParseInLocation("2006-01-02", s, time.UTC)
+		Expect(err).NotTo(HaveOccurred())
+		return t
+	}
+
+	backups := func(days ...string) []retention.Backup {
+		bs := make([]retention.Backup, len(days))
+		for i, d := range days {
+			bs[i] = retention.Backup{Info: koofrclient.FileInfo{Name: d}, Time: day(d)}
+		}
+		return bs
+	}
+
+	kept := func(decisions []retention.Decision) []string {
+		names := []string{}
+		for _, d := range decisions {
+			if d.Keep {
+				names = append(names, d.Info.Name)
+			}
+		}
+		return names
+	}
+
+	Describe("Decide", func() {
+		It("should keep the newest backups", func() {
+			decisions := retention.Policy{Last: 2}.Decide(backups("2026-01-01", "2026-01-03", "2026-01-02"))
+			Expect(kept(decisions)).To(Equal([]string{"2026-01-03", "2026-01-02"}))
+			Expect(decisions[2].Keep).To(BeFalse())
+		})
+
+		It("should keep the newest backup per period", func() {
+			decisions := retention.Policy{Daily: 2, Weekly: 2, Monthly: 2, Yearly: 3}.Decide(backups(
+				"2024-06-01", "2025-11-30", "2025-12-01", "2025-12-24",
+				"2025-12-30", "2025-12-31", "2026-01-01", "2026-01-01",
+			))
+			Expect(kept(decisions)).To(Equal([]string{"2026-01-01", "2025-12-31", "2025-12-24", "2024-06-01"}))
+			Expect(decisions[0].Reasons).To(Equal([]string{"daily", "weekly", "monthly", "yearly"}))
+			Expect(decisions[2].Reasons).To(Equal([]string{"daily", "monthly", "yearly"}))
+			Expect(decisions[4].Reasons).To(Equal([]string{"weekly"}))
+			Expect(decisions[5].Reasons).To(BeNil())
+			Expect(decisions[7].Reasons).To(Equal([]string{"yearly"}))
+		})
+	})
+
+	Describe("Prune", func() {
+		var server *koofrtest.Server
+		var client *koofrclient.KoofrClient
+
+		BeforeEach(func() {
+			server = koofrtest.NewServer()
+			client = server.Client()
+
+			for _, d := range []string{"2026-10-16", "2026-10-17", "2026-10-18"} {
+				server.PutFile(koofrtest.PrimaryMountId, "/backups/db-"+d+".tar", []byte(d), day(d).UnixNano()/int64(time.Millisecond))
+			}
+			server.PutFile(koofrtest.PrimaryMountId, "/backups/folder-2026-10-15/data", []byte("data"), 0)
+			server.PutFile(koofrtest.PrimaryMountId, "/backups/notes.txt", []byte("notes"), 0)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should refuse a policy that keeps nothing", func() {
+			_, err := retention.Prune(client, koofrtest.PrimaryMountId, "/backups", nil)
+			Expect(err).To(Equal(retention.ErrEmptyPolicy))
+		})
+
+		It("should refuse to prune without selecting backups", func() {
+			_, err := retention.Prune(client, koofrtest.PrimaryMountId, "/backups", &retention.Options{
+				Policy: retention.Policy{Last: 1},
+			})
+			Expect(err).To(Equal(retention.ErrNoSelection))
+
+			decisions, err := retention.Prune(client, koofrtest.PrimaryMountId, "/backups", &retention.Options{
+				Policy: retention.Policy{Last: 1},
+				All:    true,
+				DryRun: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decisions).To(HaveLen(5))
+		})
+
+		It("should only report in a dry run", func() {
+			decisions, err := retention.Prune(client, koofrtest.PrimaryMountId, "/backups", &retention.Options{
+				Policy:     retention.Policy{Last: 1},
+				TimeFormat: "2006-01-02",
+				Location:   time.UTC,
+				DryRun:     true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decisions).To(HaveLen(4))
+			Expect(decisions[0].String()).To(Equal("keep   2026-10-18T00:00:00Z /backups/db-2026-10-18.tar (last)"))
+			Expect(decisions[3].String()).To(Equal("remove 2026-10-15T00:00:00Z /backups/folder-2026-10-15"))
+
+			_, ok := server.ReadFile(koofrtest.PrimaryMountId, "/backups/db-2026-10-16.tar")
+			Expect(ok).To(BeTrue())
+		})
+
+		It("should delete backups not kept", func() {
+			_, err := retention.Prune(client, koofrtest.PrimaryMountId, "/backups", &retention.Options{
+				Policy:     retention.Policy{Last: 1},
+				Pattern:    "db-*",
+				TimeFormat: "2006-01-02",
+				Location:   time.UTC,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			files, err := client.FilesList(koofrtest.PrimaryMountId, "/backups")
+			Expect(err).NotTo(HaveOccurred())
+
+			names := []string{}
+			for _, f := range files {
+				names = append(names, f.Name)
+			}
+			Expect(names).To(ConsistOf("db-2026-10-18.tar", "folder-2026-10-15", "notes.txt"))
+		})
+
+		It("should use the modified time without a time format", func() {
+			backups, err := retention.List(client, koofrtest.PrimaryMountId, "/backups", &retention.Options{
+				Pattern:  "*.tar",
+				Location: time.UTC,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backups).To(HaveLen(3))
+			Expect(backups[0].Time).To(Equal(day("2026-10-16")))
+		})
+	})
+})
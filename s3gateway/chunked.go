@@ -0,0 +1,100 @@
+package s3gateway
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidChunk = fmt.Errorf("Invalid aws-chunked encoding")
+
+// isChunked reports whether the body uses the aws-chunked encoding SDKs use
+// for streaming signed uploads and uploads with trailing checksums.
+func isChunked(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") ||
+		strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked")
+}
+
+// chunkedReader decodes an aws-chunked body, a sequence of
+// "<hex size>[;chunk-signature=...]\r\n<data>\r\n" chunks ending with a zero
+// sized chunk and optional trailers. Signatures and checksums are not
+// verified.
+type chunkedReader struct {
+	r       *bufio.Reader
+	left    int64
+	started bool
+	done    bool
+}
+
+func newChunkedReader(r io.Reader) *chunkedReader {
+	return &chunkedReader{
+		r: bufio.NewReader(r),
+	}
+}
+
+func (c *chunkedReader) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+func (c *chunkedReader) Read(p []byte) (n int, err error) {
+	if c.done {
+		return 0, io.EOF
+	}
+
+	if c.left == 0 {
+		if c.started {
+			if line, err := c.readLine(); err != nil || line != "" {
+				return 0, ErrInvalidChunk
+			}
+		}
+
+		c.started = true
+
+		line, err := c.readLine()
+
+		if err != nil {
+			return 0, err
+		}
+
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = line[:i]
+		}
+
+		c.left, err = strconv.ParseInt(strings.TrimSpace(line), 16, 64)
+
+		if err != nil || c.left < 0 {
+			return 0, ErrInvalidChunk
+		}
+
+		if c.left == 0 {
+			c.done = true
+			return 0, io.EOF
+		}
+	}
+
+	if int64(len(p)) > c.left {
+		p = p[:c.left]
+	}
+
+	n, err = c.r.Read(p)
+	c.left -= int64(n)
+
+	if err == io.EOF {
+		if c.left > 0 {
+			err = io.ErrUnexpectedEOF
+		} else {
+			err = nil
+		}
+	}
+
+	return
+}
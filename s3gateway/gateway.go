@@ -0,0 +1,145 @@
+// Package s3gateway serves Koofr mounts through a minimal S3 compatible API.
+//
+// Mounts are exposed as buckets and files as objects keyed by their path
+// without the leading slash. Only path-style requests are supported, and
+// ListBuckets, HeadBucket, ListObjectsV2, GetObject, HeadObject, PutObject,
+// CopyObject and DeleteObject are implemented. Request signatures are not
+// verified: every request is made with the client the gateway was created
+// with, so the gateway must only be reachable by trusted clients.
+package s3gateway
+
+import (
+	"encoding/xml"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/koofr/go-httpclient"
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+const xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+type Gateway struct {
+	client   *koofrclient.KoofrClient
+	mounts   *koofrclient.MountResolver
+	listings listings
+}
+
+// NewGateway returns an http.Handler serving the mounts of client. Buckets
+// are named after the mounts; mount ids are accepted as bucket names too.
+func NewGateway(client *koofrclient.KoofrClient) *Gateway {
+	return &Gateway{
+		client: client,
+		mounts: koofrclient.NewMountResolver(client),
+	}
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	client := g.client.WithContext(r.Context())
+
+	bucket, key := strings.TrimPrefix(r.URL.Path, "/"), ""
+
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, key = bucket[:i], bucket[i+1:]
+	}
+
+	if bucket == "" {
+		if r.Method != "GET" {
+			writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+			return
+		}
+
+		g.listBuckets(w, r, client)
+		return
+	}
+
+	mount, err := g.mounts.Mount(bucket)
+
+	if err == koofrclient.ErrMountNotFound {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+		return
+	}
+
+	if err != nil {
+		writeClientError(w, r, err)
+		return
+	}
+
+	if key == "" {
+		switch r.Method {
+		case "HEAD":
+			w.WriteHeader(http.StatusOK)
+		case "GET":
+			g.listObjects(w, r, client, mount)
+		default:
+			writeError(w, r, http.StatusNotImplemented, "NotImplemented", "A header you provided implies functionality that is not implemented.")
+		}
+		return
+	}
+
+	if !validKey(key) {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", "The key is not a valid path.")
+		return
+	}
+
+	switch {
+	case r.Method == "GET" || r.Method == "HEAD":
+		g.getObject(w, r, client, mount.Id, key)
+	case r.Method == "PUT" && r.Header.Get("X-Amz-Copy-Source") != "":
+		g.copyObject(w, r, client, mount.Id, key)
+	case r.Method == "PUT":
+		g.putObject(w, r, client, mount.Id, key)
+	case r.Method == "DELETE":
+		g.deleteObject(w, r, client, mount.Id, key)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", "A header you provided implies functionality that is not implemented.")
+	}
+}
+
+// validKey reports whether key maps to a path unchanged, apart from a
+// trailing slash that marks a folder.
+func validKey(key string) bool {
+	p := "/" + strings.TrimSuffix(key, "/")
+	return p != "/" && path.Clean(p) == p
+}
+
+type errorResponse struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string
+	Message  string
+	Resource string
+}
+
+func writeXML(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string, message string) {
+	if r.Method == "HEAD" {
+		w.WriteHeader(status)
+		return
+	}
+
+	writeXML(w, status, errorResponse{
+		Code:     code,
+		Message:  message,
+		Resource: r.URL.Path,
+	})
+}
+
+func writeClientError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case koofrclient.IsNotFound(err):
+		writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+	case httpclient.IsInvalidStatusCode(err, http.StatusForbidden):
+		writeError(w, r, http.StatusForbidden, "AccessDenied", "Access Denied")
+	case err == koofrclient.ErrInsufficientSpace:
+		writeError(w, r, http.StatusBadRequest, "EntityTooLarge", err.Error())
+	default:
+		writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error())
+	}
+}
@@ -0,0 +1,340 @@
+package s3gateway_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	koofrclient "github.com/koofr/go-koofrclient"
+	"github.com/koofr/go-koofrclient/koofrtest"
+	"github.com/koofr/go-koofrclient/s3gateway"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gateway", func() {
+	var server *koofrtest.Server
+	var gateway *httptest.Server
+	var s3client *s3.Client
+	var ctx context.Context
+
+	BeforeEach(func() {
+		server = koofrtest.NewServer()
+		server.AddMount(koofrclient.Mount{Id: "dropbox", Name: "Dropbox"})
+		server.PutFile(koofrtest.PrimaryMountId, "/docs/a.txt", []byte("content"), 1562663291000)
+		server.PutFile(koofrtest.PrimaryMountId, "/docs/b/c.txt", []byte("c"), 1562663291000)
+		server.PutFile(koofrtest.PrimaryMountId, "/readme.txt", []byte("readme"), 1562663291000)
+
+		gateway = httptest.NewServer(s3gateway.NewGateway(server.Client()))
+
+		s3client = s3.New(s3.Options{
+			BaseEndpoint: aws.String(gateway.URL),
+			Region:       "us-east-1",
+			Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+			UsePathStyle: true,
+		})
+
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		gateway.Close()
+		server.Close()
+	})
+
+	keys := func(output *s3.ListObjectsV2Output) (keys []string) {
+		for _, object := range output.Contents {
+			keys = append(keys, *object.Key)
+		}
+		for _, prefix := range output.CommonPrefixes {
+			keys = append(keys, *prefix.Prefix)
+		}
+		return
+	}
+
+	It("should list mounts as buckets", func() {
+		output, err := s3client.ListBuckets(ctx, &s3.ListBucketsInput{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Buckets).To(HaveLen(2))
+		Expect(*output.Buckets[0].Name).To(Equal("Koofr"))
+		Expect(*output.Buckets[1].Name).To(Equal("Dropbox"))
+
+		_, err = s3client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String("Dropbox")})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = s3client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String("missing")})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should list objects with a delimiter", func() {
+		output, err := s3client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:    aws.String("Koofr"),
+			Delimiter: aws.String("/"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys(output)).To(Equal([]string{"readme.txt", "docs/"}))
+
+		output, err = s3client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:    aws.String("Koofr"),
+			Prefix:    aws.String("docs/"),
+			Delimiter: aws.String("/"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys(output)).To(Equal([]string{"docs/a.txt", "docs/b/"}))
+		Expect(*output.Contents[0].ETag).To(Equal(`"9a0364b9e99bb480dd25e1f0284c8555"`))
+		Expect(*output.Contents[0].Size).To(Equal(int64(7)))
+		Expect(output.Contents[0].LastModified.Unix()).To(Equal(int64(1562663291)))
+	})
+
+	It("should list objects recursively with a prefix", func() {
+		output, err := s3client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket: aws.String("Koofr"),
+			Prefix: aws.String("do"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys(output)).To(Equal([]string{"docs/a.txt", "docs/b/c.txt"}))
+	})
+
+	It("should paginate listings", func() {
+		var all []string
+
+		paginator := s3.NewListObjectsV2Paginator(s3client, &s3.ListObjectsV2Input{
+			Bucket:  aws.String("Koofr"),
+			MaxKeys: aws.Int32(1),
+		})
+
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Contents).To(HaveLen(1))
+			all = append(all, keys(output)...)
+		}
+
+		Expect(all).To(Equal([]string{"docs/a.txt", "docs/b/c.txt", "readme.txt"}))
+	})
+
+	It("should fetch the tree once when paginating", func() {
+		trees := 0
+		client := server.Client()
+		client.AddRequestHook(func(event *koofrclient.RequestEvent) {
+			if strings.HasSuffix(event.Path, "/files/tree") {
+				trees++
+			}
+		})
+		paged := httptest.NewServer(s3gateway.NewGateway(client))
+		defer paged.Close()
+
+		pagedClient := s3.New(s3.Options{
+			BaseEndpoint: aws.String(paged.URL),
+			Region:       "us-east-1",
+			Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+			UsePathStyle: true,
+		})
+
+		var all []string
+
+		paginator := s3.NewListObjectsV2Paginator(pagedClient, &s3.ListObjectsV2Input{
+			Bucket:  aws.String("Koofr"),
+			MaxKeys: aws.Int32(1),
+		})
+
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			all = append(all, keys(output)...)
+		}
+
+		Expect(all).To(Equal([]string{"docs/a.txt", "docs/b/c.txt", "readme.txt"}))
+		Expect(trees).To(Equal(1))
+	})
+
+	It("should url-encode keys when asked to", func() {
+		server.PutFile(koofrtest.PrimaryMountId, "/docs/a b+c.txt", []byte("x"), 1562663291000)
+
+		output, err := s3client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:       aws.String("Koofr"),
+			Prefix:       aws.String("docs/a "),
+			EncodingType: types.EncodingTypeUrl,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.EncodingType).To(Equal(types.EncodingTypeUrl))
+		Expect(*output.Prefix).To(Equal("docs/a%20"))
+		Expect(keys(output)).To(Equal([]string{"docs/a%20b%2Bc.txt"}))
+
+		output, err = s3client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket: aws.String("Koofr"),
+			Prefix: aws.String("docs/a "),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys(output)).To(Equal([]string{"docs/a b+c.txt"}))
+	})
+
+	It("should list nothing with max-keys 0", func() {
+		output, err := s3client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:     aws.String("Koofr"),
+			MaxKeys:    aws.Int32(0),
+			StartAfter: aws.String("docs/a.txt"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Contents).To(BeEmpty())
+		Expect(*output.IsTruncated).To(BeTrue())
+
+		output, err = s3client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            aws.String("Koofr"),
+			ContinuationToken: output.NextContinuationToken,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys(output)).To(Equal([]string{"docs/b/c.txt", "readme.txt"}))
+	})
+
+	It("should get objects and ranges", func() {
+		output, err := s3client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("Koofr"),
+			Key:    aws.String("docs/a.txt"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		data, _ := ioutil.ReadAll(output.Body)
+		output.Body.Close()
+		Expect(string(data)).To(Equal("content"))
+		Expect(*output.ETag).To(Equal(`"9a0364b9e99bb480dd25e1f0284c8555"`))
+		Expect(*output.ContentType).To(HavePrefix("text/plain"))
+
+		output, err = s3client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("Koofr"),
+			Key:    aws.String("docs/a.txt"),
+			Range:  aws.String("bytes=2-3"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		data, _ = ioutil.ReadAll(output.Body)
+		output.Body.Close()
+		Expect(string(data)).To(Equal("nt"))
+		Expect(*output.ContentRange).To(Equal("bytes 2-3/7"))
+	})
+
+	It("should head objects", func() {
+		output, err := s3client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String("Koofr"),
+			Key:    aws.String("docs/a.txt"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*output.ContentLength).To(Equal(int64(7)))
+
+		_, err = s3client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String("Koofr"),
+			Key:    aws.String("docs/missing.txt"),
+		})
+		var notFound *types.NotFound
+		Expect(errors.As(err, &notFound)).To(BeTrue())
+	})
+
+	It("should return NoSuchKey for missing objects", func() {
+		_, err := s3client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("Koofr"),
+			Key:    aws.String("docs"),
+		})
+		var noSuchKey *types.NoSuchKey
+		Expect(errors.As(err, &noSuchKey)).To(BeTrue())
+	})
+
+	It("should put objects into new folders", func() {
+		output, err := s3client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String("Dropbox"),
+			Key:    aws.String("new/folder/file.txt"),
+			Body:   bytes.NewReader([]byte("content")),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*output.ETag).To(Equal(`"9a0364b9e99bb480dd25e1f0284c8555"`))
+
+		_, err = s3client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String("Dropbox"),
+			Key:    aws.String("new/folder/file.txt"),
+			Body:   bytes.NewReader([]byte("replaced")),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		data, ok := server.ReadFile("dropbox", "/new/folder/file.txt")
+		Expect(ok).To(BeTrue())
+		Expect(string(data)).To(Equal("replaced"))
+	})
+
+	It("should decode aws-chunked uploads", func() {
+		body := "4;chunk-signature=abc\r\ncont\r\n3;chunk-signature=def\r\nent\r\n0;chunk-signature=ghi\r\nx-amz-checksum-crc32:AAAAAA==\r\n\r\n"
+		req, err := http.NewRequest("PUT", gateway.URL+"/Dropbox/chunked.txt", strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Encoding", "aws-chunked")
+		req.Header.Set("X-Amz-Content-Sha256", "STREAMING-UNSIGNED-PAYLOAD-TRAILER")
+		req.Header.Set("X-Amz-Decoded-Content-Length", "7")
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+		data, _ := server.ReadFile("dropbox", "/chunked.txt")
+		Expect(string(data)).To(Equal("content"))
+	})
+
+	It("should copy objects between buckets", func() {
+		server.PutFile("dropbox", "/a.txt", []byte("old"), 1000)
+
+		output, err := s3client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:     aws.String("Dropbox"),
+			Key:        aws.String("a.txt"),
+			CopySource: aws.String("Koofr/docs/a.txt"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*output.CopyObjectResult.ETag).To(Equal(`"9a0364b9e99bb480dd25e1f0284c8555"`))
+
+		data, _ := server.ReadFile("dropbox", "/a.txt")
+		Expect(string(data)).To(Equal("content"))
+	})
+
+	It("should keep the destination when a copy fails", func() {
+		server.PutFile("dropbox", "/a.txt", []byte("old"), 1000)
+		server.FailWith("copy", http.StatusForbidden)
+
+		_, err := s3client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:     aws.String("Dropbox"),
+			Key:        aws.String("a.txt"),
+			CopySource: aws.String("Koofr/docs/a.txt"),
+		})
+		Expect(err).To(HaveOccurred())
+
+		data, _ := server.ReadFile("dropbox", "/a.txt")
+		Expect(string(data)).To(Equal("old"))
+
+		files, err := server.Client().FilesList("dropbox", "/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("should delete objects", func() {
+		_, err := s3client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String("Koofr"),
+			Key:    aws.String("docs/a.txt"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, ok := server.ReadFile(koofrtest.PrimaryMountId, "/docs/a.txt")
+		Expect(ok).To(BeFalse())
+
+		_, err = s3client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String("Koofr"),
+			Key:    aws.String("docs/a.txt"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s3client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String("Koofr"),
+			Key:    aws.String("docs"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, ok = server.ReadFile(koofrtest.PrimaryMountId, "/docs/b/c.txt")
+		Expect(ok).To(BeTrue())
+	})
+})
@@ -0,0 +1,385 @@
+package s3gateway
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+const timeFormat = "2006-01-02T15:04:05.000Z"
+
+const DefaultMaxKeys = 1000
+
+func formatTime(ms int64) string {
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC().Format(timeFormat)
+}
+
+type owner struct {
+	ID          string
+	DisplayName string
+}
+
+type bucket struct {
+	Name         string
+	CreationDate string
+}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Owner   owner
+	Buckets []bucket `xml:"Buckets>Bucket"`
+}
+
+func (g *Gateway) listBuckets(w http.ResponseWriter, r *http.Request, client *koofrclient.KoofrClient) {
+	mounts, err := client.Mounts()
+
+	if err != nil {
+		writeClientError(w, r, err)
+		return
+	}
+
+	result := listAllMyBucketsResult{
+		Xmlns: xmlns,
+	}
+
+	seen := map[string]bool{}
+
+	for _, mount := range mounts {
+		if seen[mount.Name] {
+			continue
+		}
+		seen[mount.Name] = true
+
+		if mount.IsPrimary {
+			result.Owner = owner{ID: mount.Owner.Id, DisplayName: mount.Owner.Name}
+		}
+
+		result.Buckets = append(result.Buckets, bucket{
+			Name:         mount.Name,
+			CreationDate: formatTime(0),
+		})
+	}
+
+	writeXML(w, http.StatusOK, result)
+}
+
+type object struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int64
+	StorageClass string
+}
+
+type commonPrefix struct {
+	Prefix string
+}
+
+type listBucketResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Xmlns                 string   `xml:"xmlns,attr"`
+	Name                  string
+	Prefix                string
+	Delimiter             string `xml:",omitempty"`
+	StartAfter            string `xml:",omitempty"`
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
+	KeyCount              int
+	MaxKeys               int
+	IsTruncated           bool
+	EncodingType          string `xml:",omitempty"`
+	Contents              []object
+	CommonPrefixes        []commonPrefix
+}
+
+// encodeKey percent-encodes a key for encoding-type=url like S3 does, leaving
+// slashes alone, so it can be decoded as a path or as a query value.
+func encodeKey(key string) string {
+	return strings.NewReplacer("+", "%20", "%2F", "/").Replace(url.QueryEscape(key))
+}
+
+// entry is an object or, if prefix is set, a common prefix.
+type entry struct {
+	key    string
+	prefix bool
+	info   koofrclient.FileInfo
+}
+
+func (g *Gateway) listObjects(w http.ResponseWriter, r *http.Request, client *koofrclient.KoofrClient, mount koofrclient.Mount) {
+	query := r.URL.Query()
+
+	if query.Get("list-type") != "2" {
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", "Only ListObjectsV2 is implemented.")
+		return
+	}
+
+	result := listBucketResult{
+		Xmlns:             xmlns,
+		Name:              mount.Name,
+		Prefix:            query.Get("prefix"),
+		Delimiter:         query.Get("delimiter"),
+		StartAfter:        query.Get("start-after"),
+		ContinuationToken: query.Get("continuation-token"),
+		MaxKeys:           DefaultMaxKeys,
+		EncodingType:      query.Get("encoding-type"),
+	}
+
+	if result.EncodingType != "" && result.EncodingType != "url" {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid Encoding Method specified in Request")
+		return
+	}
+
+	if v := query.Get("max-keys"); v != "" {
+		maxKeys, err := strconv.Atoi(v)
+
+		if err != nil || maxKeys < 0 {
+			writeError(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid max-keys.")
+			return
+		}
+
+		if maxKeys < result.MaxKeys {
+			result.MaxKeys = maxKeys
+		}
+	}
+
+	after := result.StartAfter
+
+	if result.ContinuationToken != "" {
+		token, err := base64.RawURLEncoding.DecodeString(result.ContinuationToken)
+
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "InvalidArgument", "The continuation token provided is incorrect.")
+			return
+		}
+
+		after = string(token)
+	}
+
+	listingKey := func(after string) string {
+		return strings.Join([]string{mount.Id, result.Prefix, result.Delimiter, after}, "\x00")
+	}
+
+	var entries []entry
+
+	if result.ContinuationToken != "" {
+		entries = g.listings.take(listingKey(after))
+	}
+
+	if entries == nil {
+		var err error
+
+		entries, err = collect(client, mount.Id, result.Prefix, result.Delimiter)
+
+		if err != nil {
+			writeClientError(w, r, err)
+			return
+		}
+
+		i := sort.Search(len(entries), func(i int) bool {
+			return entries[i].key > after
+		})
+		entries = entries[i:]
+	}
+
+	if len(entries) > result.MaxKeys {
+		rest := entries[result.MaxKeys:]
+		entries = entries[:result.MaxKeys]
+		result.IsTruncated = true
+
+		// with max-keys=0 the listing continues where this one started
+		next := after
+
+		if len(entries) > 0 {
+			next = entries[len(entries)-1].key
+		}
+
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(next))
+
+		g.listings.put(listingKey(next), rest)
+	}
+
+	encode := func(s string) string {
+		if result.EncodingType == "url" {
+			return encodeKey(s)
+		}
+		return s
+	}
+
+	result.Prefix = encode(result.Prefix)
+	result.Delimiter = encode(result.Delimiter)
+	result.StartAfter = encode(result.StartAfter)
+
+	for _, e := range entries {
+		if e.prefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{encode(e.key)})
+		} else {
+			result.Contents = append(result.Contents, object{
+				Key:          encode(e.key),
+				LastModified: formatTime(e.info.Modified),
+				ETag:         `"` + e.info.Hash + `"`,
+				Size:         e.info.Size,
+				StorageClass: "STANDARD",
+			})
+		}
+	}
+
+	result.KeyCount = len(entries)
+
+	writeXML(w, http.StatusOK, result)
+}
+
+// collect returns the objects and common prefixes matching prefix, sorted
+// by key. With the "/" delimiter a single folder is listed; otherwise the
+// whole tree below the deepest folder in prefix is fetched.
+func collect(client *koofrclient.KoofrClient, mountId string, prefix string, delimiter string) (entries []entry, err error) {
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+
+	if delimiter == "/" {
+		var files []koofrclient.FileInfo
+
+		files, err = client.FilesList(mountId, "/"+dir)
+
+		if koofrclient.IsNotFound(err) {
+			return nil, nil
+		}
+
+		if err != nil {
+			return
+		}
+
+		for _, file := range files {
+			key := dir + file.Name
+
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			if file.Type == "dir" {
+				entries = append(entries, entry{key: key + "/", prefix: true})
+			} else {
+				entries = append(entries, entry{key: key, info: file})
+			}
+		}
+	} else {
+		var tree koofrclient.FileTree
+
+		tree, err = client.FilesTree(mountId, "/"+dir)
+
+		if koofrclient.IsNotFound(err) {
+			return nil, nil
+		}
+
+		if err != nil {
+			return
+		}
+
+		seen := map[string]bool{}
+
+		walkTree(&tree, dir, prefix, func(key string, info koofrclient.FileInfo) {
+			if delimiter != "" {
+				if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+					common := key[:len(prefix)+i+len(delimiter)]
+					if !seen[common] {
+						seen[common] = true
+						entries = append(entries, entry{key: common, prefix: true})
+					}
+					return
+				}
+			}
+
+			entries = append(entries, entry{key: key, info: info})
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	return
+}
+
+// walkTree calls fn for every file below tree whose key starts with prefix,
+// skipping folders that can not contain such keys.
+func walkTree(tree *koofrclient.FileTree, dir string, prefix string, fn func(key string, info koofrclient.FileInfo)) {
+	for _, child := range tree.Children {
+		key := dir + child.Name
+
+		if child.Type == "dir" {
+			key += "/"
+
+			if strings.HasPrefix(key, prefix) || strings.HasPrefix(prefix, key) {
+				walkTree(child, key, prefix, fn)
+			}
+		} else if strings.HasPrefix(key, prefix) {
+			fn(key, child.FileInfo)
+		}
+	}
+}
+
+// listingTTL is how long the rest of a truncated listing is kept for the
+// next page, so paginating a large tree does not fetch it again for every
+// page. At most maxListings are kept.
+const listingTTL = time.Minute
+const maxListings = 100
+
+type listing struct {
+	entries []entry
+	expires time.Time
+}
+
+type listings struct {
+	mu       sync.Mutex
+	listings map[string]*listing
+}
+
+func (l *listings) put(key string, entries []entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	if l.listings == nil {
+		l.listings = map[string]*listing{}
+	}
+
+	for k, cached := range l.listings {
+		if now.After(cached.expires) {
+			delete(l.listings, k)
+		}
+	}
+
+	if len(l.listings) >= maxListings {
+		return
+	}
+
+	l.listings[key] = &listing{entries, now.Add(listingTTL)}
+}
+
+// take returns and forgets the cached entries for key, or nil.
+func (l *listings) take(key string) []entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cached := l.listings[key]
+
+	if cached == nil {
+		return nil
+	}
+
+	delete(l.listings, key)
+
+	if time.Now().After(cached.expires) {
+		return nil
+	}
+
+	return cached.entries
+}
@@ -0,0 +1,252 @@
+package s3gateway
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/koofr/go-httpclient"
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+func keyPath(key string) string {
+	return "/" + strings.TrimSuffix(key, "/")
+}
+
+func setObjectHeaders(w http.ResponseWriter, info koofrclient.FileInfo) {
+	contentType := info.ContentType
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", `"`+info.Hash+`"`)
+}
+
+// getObject serves GetObject and HeadObject. Ranges and conditional headers
+// are handled by http.ServeContent; only the requested range is fetched.
+func (g *Gateway) getObject(w http.ResponseWriter, r *http.Request, client *koofrclient.KoofrClient, mountId string, key string) {
+	p := keyPath(key)
+
+	info, err := client.FilesInfo(mountId, p)
+
+	if err == nil && (info.Type != "file" || strings.HasSuffix(key, "/")) {
+		err = httpclient.InvalidStatusError{Got: http.StatusNotFound}
+	}
+
+	if err != nil {
+		writeClientError(w, r, err)
+		return
+	}
+
+	setObjectHeaders(w, info)
+
+	reader := client.NewFileReader(mountId, p, info.Size)
+	reader.SetRangeHeader(r.Header.Get("Range"))
+	defer reader.Close()
+
+	http.ServeContent(w, r, "", time.Unix(0, info.Modified*int64(time.Millisecond)), reader)
+}
+
+// putObject stores the body as a file, creating missing folders. A key with
+// a trailing slash and an empty body creates a folder.
+func (g *Gateway) putObject(w http.ResponseWriter, r *http.Request, client *koofrclient.KoofrClient, mountId string, key string) {
+	p := keyPath(key)
+
+	if strings.HasSuffix(key, "/") {
+		if r.ContentLength > 0 {
+			writeError(w, r, http.StatusBadRequest, "InvalidArgument", "Folder objects must be empty.")
+			return
+		}
+
+		if err := client.FilesMkdirAll(mountId, p); err != nil {
+			writeClientError(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	body := io.Reader(r.Body)
+	size := r.ContentLength
+
+	if isChunked(r) {
+		body = newChunkedReader(r.Body)
+		size = -1
+
+		if v := r.Header.Get("X-Amz-Decoded-Content-Length"); v != "" {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				size = n
+			}
+		}
+	}
+
+	if err := client.FilesMkdirAll(mountId, path.Dir(p)); err != nil {
+		writeClientError(w, r, err)
+		return
+	}
+
+	putOptions := &koofrclient.PutOptions{
+		NoRename:       true,
+		ForceOverwrite: true,
+	}
+
+	if size >= 0 {
+		putOptions.ContentLength = &size
+	}
+
+	info, err := client.FilesPutWithOptions(mountId, path.Dir(p), path.Base(p), body, putOptions)
+
+	if err != nil {
+		writeClientError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", `"`+info.Hash+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+type copyObjectResult struct {
+	XMLName      xml.Name `xml:"CopyObjectResult"`
+	Xmlns        string   `xml:"xmlns,attr"`
+	LastModified string
+	ETag         string
+}
+
+// copyObject copies with FilesCopy, replacing an existing destination.
+func (g *Gateway) copyObject(w http.ResponseWriter, r *http.Request, client *koofrclient.KoofrClient, mountId string, key string) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid copy source.")
+		return
+	}
+
+	if i := strings.Index(source, "?"); i >= 0 {
+		source = source[:i]
+	}
+
+	source = strings.TrimPrefix(source, "/")
+
+	i := strings.Index(source, "/")
+
+	if i < 0 || !validKey(source[i+1:]) || strings.HasSuffix(source, "/") {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid copy source.")
+		return
+	}
+
+	srcMount, err := g.mounts.Mount(source[:i])
+
+	if err == koofrclient.ErrMountNotFound {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+		return
+	}
+
+	if err != nil {
+		writeClientError(w, r, err)
+		return
+	}
+
+	srcPath, dstPath := keyPath(source[i+1:]), keyPath(key)
+
+	src, err := client.FilesInfo(srcMount.Id, srcPath)
+
+	if err == nil && src.Type != "file" {
+		err = httpclient.InvalidStatusError{Got: http.StatusNotFound}
+	}
+
+	if err != nil {
+		writeClientError(w, r, err)
+		return
+	}
+
+	if srcMount.Id != mountId || srcPath != dstPath {
+		dst, err := client.FilesInfo(mountId, dstPath)
+
+		switch {
+		case err == nil && dst.Type != "file":
+			writeError(w, r, http.StatusConflict, "InvalidRequest", "The destination is a folder.")
+			return
+		case err == nil:
+			err = replaceCopy(client, srcMount.Id, srcPath, mountId, dstPath, dst.Hash)
+		case koofrclient.IsNotFound(err):
+			err = client.FilesMkdirAll(mountId, path.Dir(dstPath))
+
+			if err == nil {
+				err = client.FilesCopy(srcMount.Id, srcPath, mountId, dstPath, koofrclient.CopyOptions{})
+			}
+		}
+
+		if err != nil {
+			writeClientError(w, r, err)
+			return
+		}
+	}
+
+	info, err := client.FilesInfo(mountId, dstPath)
+
+	if err != nil {
+		writeClientError(w, r, err)
+		return
+	}
+
+	writeXML(w, http.StatusOK, copyObjectResult{
+		Xmlns:        xmlns,
+		LastModified: formatTime(info.Modified),
+		ETag:         `"` + info.Hash + `"`,
+	})
+}
+
+// replaceCopy copies the source next to the existing destination first, so
+// a failed copy leaves the destination untouched, and then swaps it in. The
+// destination is replaced only if its hash is still dstHash.
+func replaceCopy(client *koofrclient.KoofrClient, srcMountId string, srcPath string, mountId string, dstPath string, dstHash string) (err error) {
+	tmpPath := path.Join(path.Dir(dstPath), fmt.Sprintf(".%s.%d.copy", path.Base(dstPath), time.Now().UnixNano()))
+
+	if err = client.FilesCopy(srcMountId, srcPath, mountId, tmpPath, koofrclient.CopyOptions{}); err != nil {
+		return
+	}
+
+	if err = client.FilesDeleteWithOptions(mountId, dstPath, &koofrclient.DeleteOptions{RemoveIfHash: &dstHash}); err != nil {
+		client.FilesDelete(mountId, tmpPath)
+		return
+	}
+
+	if err = client.FilesMove(mountId, tmpPath, mountId, dstPath); err != nil {
+		return fmt.Errorf("Copy was left at %s: %s", tmpPath, err)
+	}
+
+	return
+}
+
+// deleteObject deletes a file, or an empty folder for a key with a trailing
+// slash. Like S3, it succeeds if there is nothing to delete.
+func (g *Gateway) deleteObject(w http.ResponseWriter, r *http.Request, client *koofrclient.KoofrClient, mountId string, key string) {
+	p := keyPath(key)
+
+	info, err := client.FilesInfo(mountId, p)
+
+	switch {
+	case err != nil:
+	case strings.HasSuffix(key, "/") && info.Type == "dir":
+		err = client.FilesDeleteWithOptions(mountId, p, &koofrclient.DeleteOptions{RemoveIfEmpty: true})
+	case !strings.HasSuffix(key, "/") && info.Type == "file":
+		err = client.FilesDeleteWithOptions(mountId, p, &koofrclient.DeleteOptions{RemoveIfHash: &info.Hash})
+	}
+
+	if err != nil && !koofrclient.IsNotFound(err) && err != koofrclient.ErrCannotRemove {
+		writeClientError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,13 @@
+package s3gateway_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestS3gateway(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "S3gateway Suite")
+}
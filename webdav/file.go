@@ -0,0 +1,255 @@
+package webdav
+
+import (
+	"io"
+	"os"
+	"path"
+	"sync"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+)
+
+// file is an open file or folder. File content is read with a
+// koofrclient.FileReader, so seeking before reading costs no requests.
+type file struct {
+	path    string
+	info    koofrclient.FileInfo
+	reader  *koofrclient.FileReader
+	list    func() ([]koofrclient.FileInfo, error)
+	entries []os.FileInfo
+	listed  bool
+}
+
+func newFile(client *koofrclient.KoofrClient, mountId string, p string, info koofrclient.FileInfo) *file {
+	return &file{
+		path:   p,
+		info:   info,
+		reader: client.NewFileReader(mountId, p, info.Size),
+		list: func() ([]koofrclient.FileInfo, error) {
+			return client.FilesList(mountId, p)
+		},
+	}
+}
+
+func (f *file) Close() error {
+	return f.reader.Close()
+}
+
+func (f *file) Read(p []byte) (n int, err error) {
+	if f.info.Type == "dir" {
+		return 0, koofrclient.PathError("read", f.path, ErrIsDir)
+	}
+
+	n, err = f.reader.Read(p)
+
+	if err != nil && err != io.EOF {
+		err = koofrclient.PathError("read", f.path, err)
+	}
+
+	return
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	offset, err := f.reader.Seek(offset, whence)
+
+	if err != nil {
+		return 0, koofrclient.PathError("seek", f.path, os.ErrInvalid)
+	}
+
+	return offset, nil
+}
+
+// Readdir follows os.File.Readdir. The folder is listed on the first call.
+func (f *file) Readdir(count int) (infos []os.FileInfo, err error) {
+	if f.info.Type != "dir" {
+		return nil, koofrclient.PathError("readdir", f.path, ErrNotDir)
+	}
+
+	if !f.listed {
+		files, err := f.list()
+
+		if err != nil {
+			return nil, koofrclient.PathError("readdir", f.path, err)
+		}
+
+		f.entries = make([]os.FileInfo, len(files))
+		for i, info := range files {
+			f.entries[i] = newFileInfo(info)
+		}
+		f.listed = true
+	}
+
+	if count <= 0 {
+		infos, f.entries = f.entries, nil
+		return infos, nil
+	}
+
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+
+	infos, f.entries = f.entries[:count], f.entries[count:]
+
+	return infos, nil
+}
+
+func (f *file) Stat() (os.FileInfo, error) {
+	return newFileInfo(f.info), nil
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	return 0, koofrclient.PathError("write", f.path, os.ErrPermission)
+}
+
+// writeFile streams everything written to it into an upload that replaces
+// the file. The upload completes on Close or Stat; writing after Stat fails.
+// If a write or the copy into the file fails, the upload is aborted instead,
+// so the file is not replaced with partial content.
+type writeFile struct {
+	path string
+	pw   *io.PipeWriter
+	done chan struct{}
+	once sync.Once
+	info *koofrclient.FileInfo
+	err  error
+}
+
+// create opens p for writing. The parent folder must exist, and since
+// uploads always replace the whole file, an existing non-empty file must be
+// opened with O_TRUNC.
+func create(client *koofrclient.KoofrClient, mountId string, p string, flag int) (*writeFile, error) {
+	if p == "/" {
+		return nil, koofrclient.PathError("open", p, ErrIsDir)
+	}
+
+	dir, name := path.Dir(p), path.Base(p)
+
+	parent, err := client.FilesInfo(mountId, dir)
+
+	if err != nil {
+		return nil, koofrclient.PathError("open", p, err)
+	}
+
+	if parent.Type != "dir" {
+		return nil, koofrclient.PathError("open", p, os.ErrNotExist)
+	}
+
+	info, err := client.FilesInfo(mountId, p)
+
+	exists := err == nil
+
+	if err != nil && !koofrclient.IsNotFound(err) {
+		return nil, koofrclient.PathError("open", p, err)
+	}
+
+	switch {
+	case exists && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, koofrclient.PathError("open", p, os.ErrExist)
+	case !exists && flag&os.O_CREATE == 0:
+		return nil, koofrclient.PathError("open", p, os.ErrNotExist)
+	case exists && info.Type == "dir":
+		return nil, koofrclient.PathError("open", p, ErrIsDir)
+	case exists && info.Size > 0 && flag&os.O_TRUNC == 0:
+		return nil, koofrclient.PathError("open", p, ErrPartialWrite)
+	}
+
+	pr, pw := io.Pipe()
+
+	f := &writeFile{
+		path: p,
+		pw:   pw,
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		info, err := client.FilesPutWithOptions(mountId, dir, name, pr, &koofrclient.PutOptions{
+			NoRename:       true,
+			ForceOverwrite: true,
+		})
+
+		if err == nil {
+			pr.Close()
+		} else {
+			pr.CloseWithError(err)
+		}
+
+		f.info, f.err = info, err
+		close(f.done)
+	}()
+
+	return f, nil
+}
+
+func (f *writeFile) finish() error {
+	f.once.Do(func() {
+		f.pw.Close()
+		<-f.done
+	})
+
+	return f.err
+}
+
+// abort fails the upload with err unless it already completed.
+func (f *writeFile) abort(err error) {
+	f.once.Do(func() {
+		f.pw.CloseWithError(err)
+		<-f.done
+
+		if f.err == nil {
+			f.err = err
+		}
+	})
+}
+
+func (f *writeFile) Write(p []byte) (n int, err error) {
+	n, err = f.pw.Write(p)
+
+	if err != nil {
+		f.abort(err)
+		err = koofrclient.PathError("write", f.path, err)
+	}
+
+	return
+}
+
+// ReadFrom is used by io.Copy. The webdav PUT handler calls Stat and Close
+// even when reading the request body failed, for example when the client
+// disconnected, so read errors have to abort the upload here.
+func (f *writeFile) ReadFrom(r io.Reader) (n int64, err error) {
+	n, err = io.Copy(f.pw, r)
+
+	if err != nil {
+		f.abort(err)
+		err = koofrclient.PathError("write", f.path, err)
+	}
+
+	return
+}
+
+func (f *writeFile) Close() error {
+	return koofrclient.PathError("close", f.path, f.finish())
+}
+
+func (f *writeFile) Stat() (os.FileInfo, error) {
+	if err := f.finish(); err != nil {
+		return nil, koofrclient.PathError("stat", f.path, err)
+	}
+
+	return newFileInfo(*f.info), nil
+}
+
+func (f *writeFile) Read(p []byte) (int, error) {
+	return 0, koofrclient.PathError("read", f.path, os.ErrPermission)
+}
+
+func (f *writeFile) Seek(offset int64, whence int) (int64, error) {
+	return 0, koofrclient.PathError("seek", f.path, os.ErrInvalid)
+}
+
+func (f *writeFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, koofrclient.PathError("readdir", f.path, ErrNotDir)
+}
@@ -0,0 +1,182 @@
+package webdav
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+	"golang.org/x/net/webdav"
+)
+
+// MountsFileSystem exposes all mounts as top-level folders named after the
+// mounts. Names are resolved with a koofrclient.MountResolver, so of several
+// mounts sharing a name only one is reachable. The top level is read-only.
+type MountsFileSystem struct {
+	client *koofrclient.KoofrClient
+	mounts *koofrclient.MountResolver
+}
+
+func NewMountsFileSystem(client *koofrclient.KoofrClient) *MountsFileSystem {
+	return &MountsFileSystem{
+		client: client,
+		mounts: koofrclient.NewMountResolver(client),
+	}
+}
+
+// resolve returns the file system of the mount name refers to and the path
+// within it. For the root, fs is nil.
+func (m *MountsFileSystem) resolve(op string, name string) (fs *FileSystem, mount koofrclient.Mount, p string, err error) {
+	p = koofrclient.CleanPath(name)
+
+	if p == "/" {
+		return
+	}
+
+	parts := strings.SplitN(p[1:], "/", 2)
+
+	mount, err = m.mounts.Mount(parts[0])
+
+	if err == koofrclient.ErrMountNotFound {
+		return nil, mount, "", koofrclient.PathError(op, name, os.ErrNotExist)
+	}
+
+	if err != nil {
+		return nil, mount, "", koofrclient.PathError(op, name, err)
+	}
+
+	p = "/"
+	if len(parts) == 2 {
+		p = koofrclient.CleanPath(parts[1])
+	}
+
+	return NewFileSystem(m.client, mount.Id), mount, p, nil
+}
+
+func (m *MountsFileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	fs, _, p, err := m.resolve("mkdir", name)
+
+	if err != nil {
+		return err
+	}
+
+	if fs == nil || p == "/" {
+		return koofrclient.PathError("mkdir", name, os.ErrExist)
+	}
+
+	return fs.Mkdir(ctx, p, perm)
+}
+
+func (m *MountsFileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	fs, mount, p, err := m.resolve("open", name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fs == nil {
+		if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+			return nil, koofrclient.PathError("open", name, ErrIsDir)
+		}
+
+		return m.root(ctx), nil
+	}
+
+	f, err := fs.OpenFile(ctx, p, flag, perm)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p == "/" {
+		f.(*file).info.Name = mount.Name
+	}
+
+	return f, nil
+}
+
+func (m *MountsFileSystem) root(ctx context.Context) *file {
+	client := m.client.WithContext(ctx)
+
+	f := newFile(client, "", "/", koofrclient.FileInfo{Type: "dir"})
+
+	f.list = func() (files []koofrclient.FileInfo, err error) {
+		mounts, err := client.Mounts()
+
+		if err != nil {
+			return
+		}
+
+		for _, mount := range mounts {
+			files = append(files, koofrclient.FileInfo{
+				Name: mount.Name,
+				Type: "dir",
+			})
+		}
+
+		return
+	}
+
+	return f
+}
+
+func (m *MountsFileSystem) RemoveAll(ctx context.Context, name string) error {
+	fs, _, p, err := m.resolve("remove", name)
+
+	if err != nil {
+		return err
+	}
+
+	if fs == nil || p == "/" {
+		return koofrclient.PathError("remove", name, os.ErrPermission)
+	}
+
+	return fs.RemoveAll(ctx, p)
+}
+
+// Rename moves files within and between mounts.
+func (m *MountsFileSystem) Rename(ctx context.Context, oldName string, newName string) error {
+	_, oldMount, oldPath, err := m.resolve("rename", oldName)
+
+	if err != nil {
+		return err
+	}
+
+	_, newMount, newPath, err := m.resolve("rename", newName)
+
+	if err != nil {
+		return err
+	}
+
+	if oldMount.Id == "" || newMount.Id == "" || oldPath == "/" || newPath == "/" {
+		return koofrclient.PathError("rename", oldName, os.ErrPermission)
+	}
+
+	err = m.client.WithContext(ctx).FilesMove(oldMount.Id, oldPath, newMount.Id, newPath)
+
+	return koofrclient.PathError("rename", oldName, err)
+}
+
+func (m *MountsFileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	fs, mount, p, err := m.resolve("stat", name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fs == nil {
+		return newFileInfo(koofrclient.FileInfo{Type: "dir"}), nil
+	}
+
+	fi, err := fs.Stat(ctx, p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p == "/" {
+		fi.(*fileInfo).Info.Name = mount.Name
+	}
+
+	return fi, nil
+}
@@ -0,0 +1,135 @@
+// Package webdav serves Koofr mounts over WebDAV by implementing
+// golang.org/x/net/webdav.FileSystem on top of the Koofr client.
+package webdav
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+	"golang.org/x/net/webdav"
+)
+
+var ErrIsDir = fmt.Errorf("Is a directory")
+var ErrNotDir = fmt.Errorf("Not a directory")
+var ErrPartialWrite = fmt.Errorf("Files can only be written from the start")
+
+// NewHandler returns a WebDAV handler for fs with an in-memory lock system.
+// prefix is stripped from request paths.
+func NewHandler(fs webdav.FileSystem, prefix string) *webdav.Handler {
+	return &webdav.Handler{
+		Prefix:     prefix,
+		FileSystem: fs,
+		LockSystem: webdav.NewMemLS(),
+	}
+}
+
+// FileSystem exposes a single mount. Files are read with range requests as
+// they are read and seeked, and written by streaming the content to the
+// server, which replaces the file when the upload completes.
+type FileSystem struct {
+	client  *koofrclient.KoofrClient
+	mountId string
+}
+
+func NewFileSystem(client *koofrclient.KoofrClient, mountId string) *FileSystem {
+	return &FileSystem{
+		client:  client,
+		mountId: mountId,
+	}
+}
+
+func (fs *FileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	p := koofrclient.CleanPath(name)
+
+	if p == "/" {
+		return koofrclient.PathError("mkdir", name, os.ErrExist)
+	}
+
+	err := fs.client.WithContext(ctx).FilesNewFolder(fs.mountId, path.Dir(p), path.Base(p))
+
+	return koofrclient.PathError("mkdir", name, err)
+}
+
+func (fs *FileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	client := fs.client.WithContext(ctx)
+	p := koofrclient.CleanPath(name)
+
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		return create(client, fs.mountId, p, flag)
+	}
+
+	info, err := client.FilesInfo(fs.mountId, p)
+
+	if err != nil {
+		return nil, koofrclient.PathError("open", name, err)
+	}
+
+	return newFile(client, fs.mountId, p, info), nil
+}
+
+// RemoveAll deletes a file or a folder with its content. The mount root can
+// not be removed.
+func (fs *FileSystem) RemoveAll(ctx context.Context, name string) error {
+	p := koofrclient.CleanPath(name)
+
+	if p == "/" {
+		return koofrclient.PathError("remove", name, os.ErrPermission)
+	}
+
+	err := fs.client.WithContext(ctx).FilesDelete(fs.mountId, p)
+
+	if koofrclient.IsNotFound(err) {
+		return nil
+	}
+
+	return koofrclient.PathError("remove", name, err)
+}
+
+func (fs *FileSystem) Rename(ctx context.Context, oldName string, newName string) error {
+	oldPath, newPath := koofrclient.CleanPath(oldName), koofrclient.CleanPath(newName)
+
+	if oldPath == "/" || newPath == "/" {
+		return koofrclient.PathError("rename", oldName, os.ErrPermission)
+	}
+
+	err := fs.client.WithContext(ctx).FilesMove(fs.mountId, oldPath, fs.mountId, newPath)
+
+	return koofrclient.PathError("rename", oldName, err)
+}
+
+func (fs *FileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	info, err := fs.client.WithContext(ctx).FilesInfo(fs.mountId, koofrclient.CleanPath(name))
+
+	if err != nil {
+		return nil, koofrclient.PathError("stat", name, err)
+	}
+
+	return newFileInfo(info), nil
+}
+
+// fileInfo adds webdav.ETager and webdav.ContentTyper to OSFileInfo so that
+// properties are served without reading the content.
+type fileInfo struct {
+	koofrclient.OSFileInfo
+}
+
+func newFileInfo(info koofrclient.FileInfo) *fileInfo {
+	return &fileInfo{koofrclient.OSFileInfo{Info: info}}
+}
+
+func (fi *fileInfo) ETag(ctx context.Context) (string, error) {
+	if fi.Info.Hash == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return `"` + fi.Info.Hash + `"`, nil
+}
+
+func (fi *fileInfo) ContentType(ctx context.Context) (string, error) {
+	if fi.Info.ContentType == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return fi.Info.ContentType, nil
+}
@@ -0,0 +1,236 @@
+package webdav_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	koofrclient "github.com/koofr/go-koofrclient"
+	"github.com/koofr/go-koofrclient/koofrtest"
+	"github.com/koofr/go-koofrclient/webdav"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webdav", func() {
+	var server *koofrtest.Server
+	var client *koofrclient.KoofrClient
+	var ctx context.Context
+
+	BeforeEach(func() {
+		server = koofrtest.NewServer()
+		client = server.Client()
+		ctx = context.Background()
+		server.PutFile(koofrtest.PrimaryMountId, "/dir/file.txt", []byte("content"), 1562663291000)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("FileSystem", func() {
+		var fs *webdav.FileSystem
+
+		BeforeEach(func() {
+			fs = webdav.NewFileSystem(client, koofrtest.PrimaryMountId)
+		})
+
+		It("should stat files", func() {
+			fi, err := fs.Stat(ctx, "/dir/file.txt")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Name()).To(Equal("file.txt"))
+			Expect(fi.Size()).To(Equal(int64(7)))
+			Expect(fi.IsDir()).To(BeFalse())
+			Expect(fi.ModTime().Unix()).To(Equal(int64(1562663291)))
+
+			_, err = fs.Stat(ctx, "/missing")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("should read and seek files", func() {
+			f, err := fs.OpenFile(ctx, "/dir/file.txt", os.O_RDONLY, 0)
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+
+			_, err = f.Seek(2, io.SeekStart)
+			Expect(err).NotTo(HaveOccurred())
+			buf := make([]byte, 2)
+			_, err = io.ReadFull(f, buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(buf)).To(Equal("nt"))
+
+			_, err = f.Seek(-2, io.SeekEnd)
+			Expect(err).NotTo(HaveOccurred())
+			data, err := ioutil.ReadAll(f)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("nt"))
+		})
+
+		It("should read folders in batches", func() {
+			server.PutFile(koofrtest.PrimaryMountId, "/dir/other.txt", []byte("x"), 1000)
+
+			f, err := fs.OpenFile(ctx, "/dir", os.O_RDONLY, 0)
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+
+			infos, err := f.Readdir(1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(infos).To(HaveLen(1))
+			infos, err = f.Readdir(1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(infos).To(HaveLen(1))
+			_, err = f.Readdir(1)
+			Expect(err).To(Equal(io.EOF))
+		})
+
+		It("should write files", func() {
+			f, err := fs.OpenFile(ctx, "/dir/file.txt", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = io.WriteString(f, "new content")
+			Expect(err).NotTo(HaveOccurred())
+			fi, err := f.Stat()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Size()).To(Equal(int64(11)))
+			Expect(f.Close()).To(Succeed())
+
+			data, _ := server.ReadFile(koofrtest.PrimaryMountId, "/dir/file.txt")
+			Expect(string(data)).To(Equal("new content"))
+		})
+
+		It("should fail to write into a missing folder", func() {
+			_, err := fs.OpenFile(ctx, "/missing/file.txt", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("should create, rename and remove folders", func() {
+			Expect(fs.Mkdir(ctx, "/new", 0755)).To(Succeed())
+			Expect(os.IsExist(fs.Mkdir(ctx, "/new", 0755))).To(BeTrue())
+			Expect(fs.Rename(ctx, "/new", "/renamed")).To(Succeed())
+			Expect(fs.RemoveAll(ctx, "/renamed")).To(Succeed())
+			Expect(fs.RemoveAll(ctx, "/renamed")).To(Succeed())
+
+			_, err := fs.Stat(ctx, "/renamed")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("should not remove the mount root", func() {
+			Expect(os.IsPermission(fs.RemoveAll(ctx, "/"))).To(BeTrue())
+		})
+	})
+
+	Describe("MountsFileSystem", func() {
+		var fs *webdav.MountsFileSystem
+
+		BeforeEach(func() {
+			server.AddMount(koofrclient.Mount{Id: "dropbox", Name: "Dropbox"})
+			fs = webdav.NewMountsFileSystem(client)
+		})
+
+		It("should list mounts as folders", func() {
+			f, err := fs.OpenFile(ctx, "/", os.O_RDONLY, 0)
+			Expect(err).NotTo(HaveOccurred())
+			infos, err := f.Readdir(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(infos).To(HaveLen(2))
+			Expect(infos[0].Name()).To(Equal("Koofr"))
+			Expect(infos[0].IsDir()).To(BeTrue())
+			Expect(infos[1].Name()).To(Equal("Dropbox"))
+
+			fi, err := fs.Stat(ctx, "/Dropbox")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Name()).To(Equal("Dropbox"))
+		})
+
+		It("should move files between mounts", func() {
+			Expect(fs.Rename(ctx, "/Koofr/dir/file.txt", "/Dropbox/file.txt")).To(Succeed())
+
+			data, ok := server.ReadFile("dropbox", "/file.txt")
+			Expect(ok).To(BeTrue())
+			Expect(string(data)).To(Equal("content"))
+		})
+
+		It("should not find unknown mounts", func() {
+			_, err := fs.Stat(ctx, "/Unknown/file.txt")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Handler", func() {
+		var dav *httptest.Server
+
+		BeforeEach(func() {
+			dav = httptest.NewServer(webdav.NewHandler(webdav.NewFileSystem(client, koofrtest.PrimaryMountId), "/dav"))
+		})
+
+		AfterEach(func() {
+			dav.Close()
+		})
+
+		do := func(method string, path string, body string, headers map[string]string) (*http.Response, string) {
+			req, err := http.NewRequest(method, dav.URL+path, strings.NewReader(body))
+			Expect(err).NotTo(HaveOccurred())
+			for k, v := range headers {
+				req.Header.Set(k, v)
+			}
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+			data, err := ioutil.ReadAll(res.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return res, string(data)
+		}
+
+		It("should serve ranges with the file hash as ETag", func() {
+			res, body := do("GET", "/dav/dir/file.txt", "", map[string]string{"Range": "bytes=2-3"})
+			Expect(res.StatusCode).To(Equal(http.StatusPartialContent))
+			Expect(body).To(Equal("nt"))
+			Expect(res.Header.Get("ETag")).To(Equal(`"9a0364b9e99bb480dd25e1f0284c8555"`))
+		})
+
+		It("should upload files and list folders", func() {
+			res, _ := do("PUT", "/dav/dir/new.txt", "new", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusCreated))
+
+			res, body := do("PROPFIND", "/dav/dir/", "", map[string]string{"Depth": "1"})
+			Expect(res.StatusCode).To(Equal(http.StatusMultiStatus))
+			Expect(body).To(ContainSubstring("/dav/dir/new.txt"))
+			Expect(body).To(ContainSubstring("/dav/dir/file.txt"))
+		})
+
+		It("should keep the old content when a PUT is aborted", func() {
+			done := make(chan struct{})
+			handler := webdav.NewHandler(webdav.NewFileSystem(client, koofrtest.PrimaryMountId), "/dav")
+			aborted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer close(done)
+				handler.ServeHTTP(w, r)
+			}))
+			defer aborted.Close()
+
+			conn, err := net.Dial("tcp", aborted.Listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = io.WriteString(conn, "PUT /dav/dir/file.txt HTTP/1.1\r\nHost: dav\r\nContent-Length: 100\r\n\r\npartial")
+			Expect(err).NotTo(HaveOccurred())
+			conn.Close()
+			Eventually(done).Should(BeClosed())
+
+			data, ok := server.ReadFile(koofrtest.PrimaryMountId, "/dir/file.txt")
+			Expect(ok).To(BeTrue())
+			Expect(string(data)).To(Equal("content"))
+		})
+
+		It("should move and delete files", func() {
+			res, _ := do("MOVE", "/dav/dir/file.txt", "", map[string]string{"Destination": dav.URL + "/dav/moved.txt"})
+			Expect(res.StatusCode).To(Equal(http.StatusCreated))
+			res, _ = do("DELETE", "/dav/moved.txt", "", nil)
+			Expect(res.StatusCode).To(Equal(http.StatusNoContent))
+
+			_, ok := server.ReadFile(koofrtest.PrimaryMountId, "/moved.txt")
+			Expect(ok).To(BeFalse())
+		})
+	})
+})